@@ -0,0 +1,98 @@
+package actor
+
+import (
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/exp/slices"
+)
+
+// Keys of the locals of the requests
+const (
+	// ContextKey is the key of the JWT
+	ContextKey = "user"
+	// ScopeKey is the key of the scope that granted the access to the request
+	ScopeKey = "scope"
+)
+
+// Actor identifies the user who made a request, from the claims of its JWT.
+type Actor struct {
+	Subject string
+	Name    string
+	Scopes  []string
+	// Scope is the scope that granted the access to the request, if any
+	Scope string
+	// Authenticated is false when the authentication is disabled
+	Authenticated bool
+}
+
+// FromContext returns the actor of the request, once it was authenticated.
+func FromContext(c *fiber.Ctx) Actor {
+	token, ok := c.Locals(ContextKey).(*jwt.Token)
+	if !ok {
+		return Actor{}
+	}
+
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return Actor{Authenticated: true}
+	}
+
+	a := Actor{Authenticated: true}
+	a.Subject, _ = claims["sub"].(string)
+	a.Name, _ = claims["name"].(string)
+	a.Scopes, _ = ParseScope(claims)
+	a.Scope, _ = c.Locals(ScopeKey).(string)
+
+	return a
+}
+
+// HasAnyScope reports whether the actor holds any of the scopes. Every actor holds all the scopes
+// when the authentication is disabled.
+func (a Actor) HasAnyScope(scopes ...string) bool {
+	if !a.Authenticated {
+		return true
+	}
+
+	for _, scope := range scopes {
+		if slices.Contains(a.Scopes, scope) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// DisplayName returns the name of the actor, or its subject when the name is unknown.
+func (a Actor) DisplayName() string {
+	if a.Name != "" {
+		return a.Name
+	}
+
+	return a.Subject
+}
+
+// ParseScope returns the scopes of the 'scope' claim, either a space separated string or a list.
+func ParseScope(claims jwt.MapClaims) ([]string, error) {
+	var scopes []string
+
+	switch v := claims["scope"].(type) {
+	case string:
+		scopes = strings.Fields(v)
+	case []string:
+		scopes = v
+	case []interface{}:
+		for _, a := range v {
+			scope, ok := a.(string)
+			if !ok {
+				return nil, jwt.ErrInvalidType
+			}
+			scopes = append(scopes, scope)
+		}
+	default:
+		return nil, jwt.ErrInvalidType
+	}
+
+	return scopes, nil
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api/actor"
+	"github.com/gringolito/dnsmasq-manager/api/handler"
+	"github.com/gringolito/dnsmasq-manager/pkg/audit"
+	"golang.org/x/exp/slog"
+)
+
+// auditHandler records the changes made by every request into the audit log, on behalf of its
+// actor. Only the requests whose handler reported a change are recorded, so neither the rejected
+// requests nor the dry runs are.
+func auditHandler(log *audit.Log) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() == http.MethodGet || c.Method() == http.MethodHead {
+			return c.Next()
+		}
+
+		err := c.Next()
+
+		before, after := c.Locals(handler.AuditBeforeKey), c.Locals(handler.AuditAfterKey)
+		if before == nil && after == nil {
+			return err
+		}
+
+		a := actor.FromContext(c)
+		requestId, _ := c.Locals("requestid").(string)
+		entry := &audit.Entry{
+			Time:      time.Now().UTC(),
+			Subject:   a.Subject,
+			Name:      a.Name,
+			Scope:     a.Scope,
+			RequestID: requestId,
+			SourceIP:  c.IP(),
+			Operation: c.Method() + " " + c.OriginalURL(),
+			Status:    c.Response().StatusCode(),
+			Before:    auditValue(before),
+			After:     auditValue(after),
+		}
+		if appendErr := log.Append(entry); appendErr != nil {
+			slog.Error("Error recording the change into the audit log",
+				slog.String("requestId", requestId),
+				slog.String("error", appendErr.Error()),
+			)
+		}
+
+		return err
+	}
+}
+
+func auditValue(value interface{}) json.RawMessage {
+	if value == nil {
+		return nil
+	}
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		slog.Error("Error encoding the audited value", slog.String("error", err.Error()))
+		return nil
+	}
+
+	return data
+}
@@ -22,6 +22,28 @@ const (
 		"Please re-authenticate and try again."
 )
 
+var ErrMissingSigningKey = errors.New("missing auth signing key")
+
+// CheckSigningKey loads the signing key of the authentication again, failing when it is empty or
+// cannot be loaded, as when its file was removed or holds no valid key.
+func CheckSigningKey(cfg *config.Config) error {
+	if cfg.Auth.Method == config.NoAuth {
+		return nil
+	}
+
+	_, pemEncoded, err := getAuthSigningMethod(cfg.Auth.Method)
+	if err != nil {
+		return err
+	}
+
+	if cfg.Auth.Key == "" {
+		return ErrMissingSigningKey
+	}
+
+	_, err = getAuthSigningKey(cfg.Auth.Key, pemEncoded)
+	return err
+}
+
 func setupJwtConfig(cfg *config.Config) (*jwtware.Config, error) {
 	if cfg.Auth.Method == config.NoAuth {
 		return nil, nil
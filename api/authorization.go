@@ -1,10 +1,9 @@
 package api
 
 import (
-	"strings"
-
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/gringolito/dnsmasq-manager/api/actor"
 	"github.com/gringolito/dnsmasq-manager/api/presenter"
 	"golang.org/x/exp/slices"
 	"golang.org/x/exp/slog"
@@ -20,9 +19,9 @@ func authorizationHandler(jwtContextKey string, roles []string) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		token := c.Locals(jwtContextKey).(*jwt.Token)
 		claims := token.Claims.(jwt.MapClaims)
-		name := claims["name"].(string)
+		name, _ := claims["name"].(string)
 
-		scopes, err := parseClaimScope(claims)
+		scopes, err := actor.ParseScope(claims)
 		if err != nil {
 			slog.Debug("Authorization denied: malformed JWT claim 'scope'",
 				slog.String("user", name),
@@ -35,6 +34,7 @@ func authorizationHandler(jwtContextKey string, roles []string) fiber.Handler {
 		for _, role := range roles {
 			if slices.Contains[string](scopes, role) {
 				authorized = true
+				c.Locals(actor.ScopeKey, role)
 				slog.Debug("Authorization granted",
 					slog.String("user", name),
 					slog.String("role", role),
@@ -53,26 +53,3 @@ func authorizationHandler(jwtContextKey string, roles []string) fiber.Handler {
 		return c.Next()
 	}
 }
-
-func parseClaimScope(claims jwt.MapClaims) ([]string, error) {
-	var scopes []string
-
-	switch v := claims["scope"].(type) {
-	case string:
-		scopes = strings.Fields(v)
-	case []string:
-		scopes = v
-	case []interface{}:
-		for _, a := range v {
-			scope, ok := a.(string)
-			if !ok {
-				return nil, jwt.ErrInvalidType
-			}
-			scopes = append(scopes, scope)
-		}
-	default:
-		return nil, jwt.ErrInvalidType
-	}
-
-	return scopes, nil
-}
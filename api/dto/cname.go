@@ -0,0 +1,27 @@
+package dto
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+type Cname struct {
+	Aliases []string `validate:"required,min=1,dive,hostname_rfc1123"`
+	Target  string   `validate:"required,hostname_rfc1123"`
+	TTL     int      `json:",omitempty" validate:"min=0"`
+}
+
+func NewCname(cname *model.Cname) *Cname {
+	return &Cname{
+		Aliases: cname.Aliases,
+		Target:  cname.Target,
+		TTL:     cname.TTL,
+	}
+}
+
+func (c *Cname) ToModel() *model.Cname {
+	return &model.Cname{
+		Aliases: c.Aliases,
+		Target:  c.Target,
+		TTL:     c.TTL,
+	}
+}
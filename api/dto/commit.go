@@ -0,0 +1,42 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/git"
+)
+
+type Commit struct {
+	Hash        string
+	AuthorName  string
+	AuthorEmail string `json:",omitempty"`
+	Time        time.Time
+	Subject     string
+	Body        string `json:",omitempty"`
+	Files       []string
+}
+
+func NewCommit(commit *git.Commit) *Commit {
+	return &Commit{
+		Hash:        commit.Hash,
+		AuthorName:  commit.Author.Name,
+		AuthorEmail: commit.Author.Email,
+		Time:        commit.Time.UTC(),
+		Subject:     commit.Subject,
+		Body:        commit.Body,
+		Files:       commit.Files,
+	}
+}
+
+type CommitContent struct {
+	Commit
+	// Diff is the unified diff of the managed files made by the commit
+	Diff string
+}
+
+func NewCommitContent(commit *git.Commit, diff string) *CommitContent {
+	return &CommitContent{
+		Commit: *NewCommit(commit),
+		Diff:   diff,
+	}
+}
@@ -0,0 +1,47 @@
+package dto
+
+import (
+	"net"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+type DnsRecord struct {
+	Type      string   `validate:"required,dnsrecordtype"`
+	Names     []string `validate:"required,min=1,dive,hostname_rfc1123"`
+	Addresses []string `json:",omitempty" validate:"omitempty,dive,ip"`
+	Target    string   `json:",omitempty" validate:"omitempty,hostname_rfc1123"`
+	TTL       int      `json:",omitempty" validate:"min=0"`
+}
+
+func NewDnsRecord(record *model.DnsRecord) *DnsRecord {
+	dto := &DnsRecord{
+		Type:   string(record.Type),
+		Names:  record.Names,
+		Target: record.Target,
+		TTL:    record.TTL,
+	}
+
+	for _, address := range record.Addresses {
+		dto.Addresses = append(dto.Addresses, address.String())
+	}
+
+	return dto
+}
+
+func (r *DnsRecord) ToModel() *model.DnsRecord {
+	record := &model.DnsRecord{
+		Type:   model.DnsRecordType(r.Type),
+		Names:  r.Names,
+		Target: r.Target,
+		TTL:    r.TTL,
+	}
+
+	for _, address := range r.Addresses {
+		if ip := net.ParseIP(address); ip != nil {
+			record.Addresses = append(record.Addresses, ip)
+		}
+	}
+
+	return record
+}
@@ -0,0 +1,6 @@
+package dto
+
+// File is a managed file, reported by the file events.
+type File struct {
+	Path string
+}
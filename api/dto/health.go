@@ -0,0 +1,33 @@
+package dto
+
+import "github.com/gringolito/dnsmasq-manager/pkg/health"
+
+type Health struct {
+	Status string
+	Checks []HealthCheck `json:",omitempty"`
+}
+
+type HealthCheck struct {
+	Name   string
+	Status string
+	Error  string `json:",omitempty"`
+}
+
+func NewHealth(results []health.Result, ready bool) *Health {
+	h := &Health{
+		Status: health.Pass,
+		Checks: make([]HealthCheck, 0, len(results)),
+	}
+	if !ready {
+		h.Status = health.Fail
+	}
+	for _, r := range results {
+		h.Checks = append(h.Checks, HealthCheck{
+			Name:   r.Name,
+			Status: r.Status,
+			Error:  r.Error,
+		})
+	}
+
+	return h
+}
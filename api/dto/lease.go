@@ -0,0 +1,34 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+type Lease struct {
+	// Expiry is omitted for infinite leases
+	Expiry     *time.Time `json:",omitempty"`
+	MacAddress string     `json:",omitempty"`
+	IAID       string     `json:",omitempty"`
+	IPAddress  string
+	HostName   string `json:",omitempty"`
+	ClientID   string `json:",omitempty"`
+}
+
+func NewLease(lease *model.Lease) *Lease {
+	dto := &Lease{
+		MacAddress: lease.MacAddress.String(),
+		IAID:       lease.IAID,
+		IPAddress:  lease.IPAddress.String(),
+		HostName:   lease.HostName,
+		ClientID:   lease.ClientID,
+	}
+
+	if !lease.IsInfinite() {
+		expiry := lease.Expiry.UTC()
+		dto.Expiry = &expiry
+	}
+
+	return dto
+}
@@ -0,0 +1,6 @@
+package dto
+
+type LeasePromotion struct {
+	IPAddress string `json:",omitempty" validate:"omitempty,ip"`
+	HostName  string `json:",omitempty" validate:"omitempty,hostname,dhcphostname"`
+}
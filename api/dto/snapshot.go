@@ -0,0 +1,37 @@
+package dto
+
+import (
+	"time"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/backup"
+)
+
+type Snapshot struct {
+	ID   string
+	Time time.Time
+	Size int64
+}
+
+func NewSnapshot(snapshot *backup.Snapshot) *Snapshot {
+	return &Snapshot{
+		ID:   snapshot.ID,
+		Time: snapshot.Time,
+		Size: snapshot.Size,
+	}
+}
+
+type SnapshotContent struct {
+	Snapshot
+	Content string
+	// Diff is the unified diff from the current file to the snapshot, that is, the changes a
+	// restore would make
+	Diff string
+}
+
+func NewSnapshotContent(snapshot *backup.Snapshot, content []byte, diff string) *SnapshotContent {
+	return &SnapshotContent{
+		Snapshot: *NewSnapshot(snapshot),
+		Content:  string(content),
+		Diff:     diff,
+	}
+}
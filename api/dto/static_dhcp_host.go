@@ -7,25 +7,75 @@ import (
 )
 
 type StaticDhcpHost struct {
-	MacAddress string `validate:"required,mac"`
-	IPAddress  string `validate:"required,ipv4"`
-	HostName   string `validate:"required,hostname"`
+	MacAddress        string   `validate:"required_without_all=ClientID HostName,omitempty,hwaddr"`
+	ExtraMacAddresses []string `json:",omitempty" validate:"omitempty,dive,hwaddr"`
+	ClientID          string   `json:",omitempty" validate:"omitempty,dnsmasqtoken"`
+	SetTags           []string `json:",omitempty" validate:"omitempty,dive,dnsmasqtoken"`
+	Tags              []string `json:",omitempty" validate:"omitempty,dive,dnsmasqtoken"`
+	IPAddress         string   `validate:"omitempty,ip"`
+	IPv6Addresses     []string `json:",omitempty" validate:"omitempty,dive,ipv6addr"`
+	HostName          string   `validate:"omitempty,hostname,dhcphostname"`
+	LeaseTime         string   `json:",omitempty" validate:"omitempty,leasetime"`
+	Ignore            bool     `json:",omitempty"`
 }
 
 func NewStaticDhcpHost(host *model.StaticDhcpHost) *StaticDhcpHost {
-	return &StaticDhcpHost{
-		MacAddress: host.MacAddress.String(),
-		IPAddress:  host.IPAddress.String(),
-		HostName:   host.HostName,
+	dto := &StaticDhcpHost{
+		ClientID:  host.ClientID,
+		SetTags:   host.SetTags,
+		Tags:      host.Tags,
+		HostName:  host.HostName,
+		LeaseTime: host.LeaseTime,
+		Ignore:    host.Ignore,
 	}
+
+	if len(host.MacAddresses) > 0 {
+		dto.MacAddress = host.MacAddresses[0].String()
+		for _, mac := range host.MacAddresses[1:] {
+			dto.ExtraMacAddresses = append(dto.ExtraMacAddresses, mac.String())
+		}
+	}
+
+	if host.IPAddress != nil {
+		dto.IPAddress = host.IPAddress.String()
+	}
+
+	for _, address := range host.IPv6Addresses {
+		dto.IPv6Addresses = append(dto.IPv6Addresses, address.String())
+	}
+
+	return dto
 }
 
 func (h *StaticDhcpHost) ToModel() *model.StaticDhcpHost {
-	mac, _ := net.ParseMAC(h.MacAddress)
+	host := &model.StaticDhcpHost{
+		ClientID:  h.ClientID,
+		SetTags:   h.SetTags,
+		Tags:      h.Tags,
+		HostName:  h.HostName,
+		LeaseTime: h.LeaseTime,
+		Ignore:    h.Ignore,
+	}
+
+	for _, address := range append([]string{h.MacAddress}, h.ExtraMacAddresses...) {
+		if mac, err := model.ParseHardwareAddress(address); err == nil {
+			host.MacAddresses = append(host.MacAddresses, mac)
+		}
+	}
 
-	return &model.StaticDhcpHost{
-		MacAddress: mac,
-		IPAddress:  net.ParseIP(h.IPAddress),
-		HostName:   h.HostName,
+	// An IPv6 address given as the IP address is reserved as the first IPv6 address
+	ipAddress := net.ParseIP(h.IPAddress)
+	if ipAddress.To4() != nil {
+		host.IPAddress = ipAddress
+	} else if ipAddress != nil {
+		host.IPv6Addresses = append(host.IPv6Addresses, model.IPv6Address{IP: ipAddress})
 	}
+
+	for _, address := range h.IPv6Addresses {
+		if ipv6, err := model.ParseIPv6Address(address); err == nil {
+			host.IPv6Addresses = append(host.IPv6Addresses, ipv6)
+		}
+	}
+
+	return host
 }
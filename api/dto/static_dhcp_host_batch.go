@@ -0,0 +1,50 @@
+package dto
+
+import (
+	"net"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/host"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+type StaticDhcpHostBatch struct {
+	Operations []StaticDhcpHostOperation `validate:"required,min=1,dive"`
+}
+
+type StaticDhcpHostOperation struct {
+	Operation  string          `validate:"required,oneof=add update remove"`
+	MacAddress string          `json:",omitempty" validate:"omitempty,hwaddr"`
+	IPAddress  string          `json:",omitempty" validate:"omitempty,ip"`
+	HostName   string          `json:",omitempty" validate:"omitempty,hostname,dhcphostname"`
+	Force      bool            `json:",omitempty"`
+	Host       *StaticDhcpHost `json:",omitempty" validate:"required_unless=Operation remove"`
+}
+
+type StaticDhcpHostOperationResult struct {
+	Operation string
+	Status    int
+	Host      *StaticDhcpHost `json:",omitempty"`
+	Error     string          `json:",omitempty"`
+}
+
+func (o *StaticDhcpHostOperation) ToModel() host.Operation {
+	operation := host.Operation{
+		Action:    host.Action(o.Operation),
+		IPAddress: net.ParseIP(o.IPAddress),
+		HostName:  o.HostName,
+		Options:   host.Options{Force: o.Force},
+	}
+
+	if o.Host != nil {
+		operation.Host = o.Host.ToModel()
+	}
+
+	if mac, err := model.ParseHardwareAddress(o.MacAddress); err == nil {
+		operation.MacAddress = mac
+	} else if o.IPAddress == "" && o.HostName == "" && operation.Host != nil && len(operation.Host.MacAddresses) > 0 {
+		// Without an explicit identifier, the host is targeted by its own (first) MAC address
+		operation.MacAddress = operation.Host.MacAddresses[0]
+	}
+
+	return operation
+}
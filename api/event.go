@@ -0,0 +1,120 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api/actor"
+	"github.com/gringolito/dnsmasq-manager/api/dto"
+	"github.com/gringolito/dnsmasq-manager/api/handler"
+	"github.com/gringolito/dnsmasq-manager/api/scope"
+	"github.com/gringolito/dnsmasq-manager/pkg/event"
+	"github.com/gringolito/dnsmasq-manager/pkg/host"
+	"github.com/gringolito/dnsmasq-manager/pkg/lease"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"golang.org/x/exp/slog"
+)
+
+// eventHandler publishes an event for every static host changed by the requests, on behalf of
+// their actor.
+func eventHandler(publisher event.Publisher) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() == http.MethodGet || c.Method() == http.MethodHead {
+			return c.Next()
+		}
+
+		err := c.Next()
+
+		changes := handler.HostChanges(c)
+		if len(changes) == 0 {
+			return err
+		}
+
+		var eventActor *event.Actor
+		if a := actor.FromContext(c); a.Authenticated {
+			eventActor = &event.Actor{Subject: a.Subject, Name: a.Name}
+		}
+		requestId, _ := c.Locals("requestid").(string)
+		for _, change := range changes {
+			e := event.New(hostEventType(change))
+			e.Actor = eventActor
+			e.RequestID = requestId
+			e.Before = hostValue(change.Before)
+			e.After = hostValue(change.After)
+			e.Scopes = scope.DhcpCanRead
+			publisher.Publish(e)
+		}
+
+		return err
+	}
+}
+
+func hostEventType(change host.Change) string {
+	switch {
+	case change.Before == nil:
+		return event.HostCreated
+	case change.After == nil:
+		return event.HostDeleted
+	default:
+		return event.HostUpdated
+	}
+}
+
+func hostValue(h *model.StaticDhcpHost) json.RawMessage {
+	if h == nil {
+		return nil
+	}
+
+	return eventValue(dto.NewStaticDhcpHost(h))
+}
+
+// PublishLeaseChanges returns the function publishing an event for every lease changed by dnsmasq.
+func PublishLeaseChanges(publisher event.Publisher) func([]lease.Change) {
+	return func(changes []lease.Change) {
+		for _, change := range changes {
+			var e *event.Event
+			switch {
+			case change.Before == nil:
+				e = event.New(event.LeaseCreated)
+			case change.After == nil:
+				e = event.New(event.LeaseDeleted)
+			default:
+				e = event.New(event.LeaseUpdated)
+			}
+			e.Before = leaseValue(change.Before)
+			e.After = leaseValue(change.After)
+			e.Scopes = scope.DhcpCanRead
+			publisher.Publish(e)
+		}
+	}
+}
+
+func leaseValue(l *model.Lease) json.RawMessage {
+	if l == nil {
+		return nil
+	}
+
+	return eventValue(dto.NewLease(l))
+}
+
+// PublishFileModified returns the function publishing an event for every file modified by another
+// tool, to the users holding any of the scopes of the file.
+func PublishFileModified(publisher event.Publisher, scopes map[string][]string) func(path string) {
+	return func(path string) {
+		e := event.New(event.FileExternallyModified)
+		e.After = eventValue(&dto.File{Path: path})
+		e.Scopes = scopes[path]
+		publisher.Publish(e)
+	}
+}
+
+func eventValue(value interface{}) json.RawMessage {
+	data, err := json.Marshal(value)
+	if err != nil {
+		slog.Error("Error encoding the event value", slog.String("error", err.Error()))
+		return nil
+	}
+
+	return data
+}
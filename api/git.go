@@ -0,0 +1,96 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api/actor"
+	"github.com/gringolito/dnsmasq-manager/api/handler"
+	"github.com/gringolito/dnsmasq-manager/pkg/git"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	anonymousAuthorName    = "anonymous"
+	ExternalChangesMessage = "Changes made outside of dnsmasq Manager"
+	commitSubjectFormat    = "%s: %s %s\n\n"
+	commitRequestIdFormat  = "Request-Id: %s\n"
+)
+
+// gitHandler commits the changes made by every request, on behalf of its actor. The requests
+// changing the files are serialized along with their commits, so the changes of a request are never
+// committed by another one, nor on behalf of another actor.
+func gitHandler(repository *git.Repository, names *routeNames) fiber.Handler {
+	var mutex sync.Mutex
+
+	return func(c *fiber.Ctx) error {
+		if c.Method() == http.MethodGet || c.Method() == http.MethodHead {
+			return c.Next()
+		}
+
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		err := c.Next()
+
+		requestId, _ := c.Locals("requestid").(string)
+		message := commitMessage(c, names.lookup(c.Route()), requestId)
+		if _, commitErr := repository.Commit(commitAuthor(actor.FromContext(c)), message); commitErr != nil {
+			slog.Error("Error committing the changes",
+				slog.String("requestId", requestId),
+				slog.String("error", commitErr.Error()),
+			)
+		}
+
+		return err
+	}
+}
+
+// CommitFileModified chains fn after committing the changes of the files modified by other tools,
+// as soon as they are noticed, so they are committed apart from the changes of the requests.
+func CommitFileModified(repository *git.Repository, fn func(path string)) func(path string) {
+	return func(path string) {
+		if _, err := repository.Commit(git.Committer, ExternalChangesMessage); err != nil {
+			slog.Error("Error committing the external changes",
+				slog.String("file", path),
+				slog.String("error", err.Error()),
+			)
+		}
+		fn(path)
+	}
+}
+
+// commitMessage describes the operation of the request and the entries it changed, as reported to
+// the audit log.
+func commitMessage(c *fiber.Ctx, operation string, requestId string) string {
+	var message strings.Builder
+	fmt.Fprintf(&message, commitSubjectFormat, operation, c.Method(), c.OriginalURL())
+	before, after := auditValue(c.Locals(handler.AuditBeforeKey)), auditValue(c.Locals(handler.AuditAfterKey))
+	if before != nil {
+		fmt.Fprintf(&message, "Before: %s\n", before)
+	}
+	if after != nil {
+		fmt.Fprintf(&message, "After: %s\n", after)
+	}
+	if before != nil || after != nil {
+		message.WriteString("\n")
+	}
+	fmt.Fprintf(&message, commitRequestIdFormat, requestId)
+
+	return message.String()
+}
+
+func commitAuthor(a actor.Actor) git.Signature {
+	author := git.Signature{Name: a.DisplayName()}
+	if author.Name == "" {
+		author.Name = anonymousAuthorName
+	}
+	if strings.Contains(a.Subject, "@") {
+		author.Email = a.Subject
+	}
+
+	return author
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/gringolito/dnsmasq-manager/api/actor"
+	"github.com/gringolito/dnsmasq-manager/api/handler"
+	"github.com/gringolito/dnsmasq-manager/pkg/git"
+)
+
+func TestGitHandlerConcurrentRequests(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "hosts.conf")
+	repository, err := git.Open(dir, path)
+	if err != nil {
+		t.Fatalf("Open() failed: %v", err)
+	}
+
+	app := fiber.New()
+	app.Use(func(c *fiber.Ctx) error {
+		c.Locals(actor.ContextKey, &jwt.Token{Claims: jwt.MapClaims{"sub": strings.TrimPrefix(c.Path(), "/hosts/")}})
+		return c.Next()
+	})
+	app.Use(gitHandler(repository, newRouteNames(app)))
+	app.Post("/hosts/:user", func(c *fiber.Ctx) error {
+		user := c.Params("user")
+		data, _ := os.ReadFile(path)
+		if err := os.WriteFile(path, append(data, user+"\n"...), 0644); err != nil {
+			return err
+		}
+		// Gives the other requests the chance to change the file before this one is committed
+		time.Sleep(10 * time.Millisecond)
+		c.Locals(handler.AuditAfterKey, user)
+		return c.SendStatus(http.StatusCreated)
+	}).Name("add")
+
+	users := []string{"alice", "bob", "carol", "dave"}
+	var wg sync.WaitGroup
+	for _, user := range users {
+		wg.Add(1)
+		go func(user string) {
+			defer wg.Done()
+			request := httptest.NewRequest(http.MethodPost, "/hosts/"+user, nil)
+			if _, err := app.Test(request, -1); err != nil {
+				t.Errorf("Test() failed: %v", err)
+			}
+		}(user)
+	}
+	wg.Wait()
+
+	commits, err := repository.Log(0)
+	if err != nil {
+		t.Fatalf("Log() failed: %v", err)
+	}
+	if len(commits) != len(users) {
+		t.Fatalf("got %d commits, want %d", len(commits), len(users))
+	}
+	for _, commit := range commits {
+		user := strings.TrimPrefix(commit.Subject, "add: POST /hosts/")
+		if commit.Author.Name != user {
+			t.Errorf("commit %q authored by %q", commit.Subject, commit.Author.Name)
+		}
+		if want := fmt.Sprintf("After: %q", user); !strings.Contains(commit.Body, want) {
+			t.Errorf("commit body = %q, want it to contain %q", commit.Body, want)
+		}
+	}
+}
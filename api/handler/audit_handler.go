@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/pkg/audit"
+)
+
+// Keys of the values changed by a request, in its locals
+const (
+	AuditBeforeKey = "audit.before"
+	AuditAfterKey  = "audit.after"
+)
+
+// Details
+const (
+	MalformedTime = "The `%s` query parameter must be a time in the RFC 3339 format (e.g. 2023-06-15T18:25:01Z). " +
+		"The time that was provided was: %s."
+)
+
+// auditChange records the values changed by the request, to be audited. Either value can be nil,
+// when the request added or removed it.
+func auditChange(c *fiber.Ctx, before interface{}, after interface{}) {
+	c.Locals(AuditBeforeKey, before)
+	c.Locals(AuditAfterKey, after)
+}
+
+func parseTimeQuery(c *fiber.Ctx, key string) (time.Time, bool) {
+	value := c.Query(key)
+	if value == "" {
+		return time.Time{}, true
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		presenter.BadRequestResponse(c, InvalidQueryMessage, fmt.Sprintf(MalformedTime, key, value))
+		return time.Time{}, false
+	}
+
+	return t, true
+}
+
+func GetAuditLog(log *audit.Log) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		q := audit.Query{Subject: c.Query("subject")}
+
+		var ok bool
+		if q.Since, ok = parseTimeQuery(c, "since"); !ok {
+			return nil
+		}
+		if q.Until, ok = parseTimeQuery(c, "until"); !ok {
+			return nil
+		}
+
+		if value := c.Query("limit"); value != "" {
+			var err error
+			if q.Limit, err = strconv.Atoi(value); err != nil || q.Limit < 0 {
+				return presenter.BadRequestResponse(c, InvalidQueryMessage, InvalidLimit)
+			}
+		}
+
+		entries, err := log.Find(q)
+		if err != nil {
+			return presenter.InternalServerErrorResponse(c)
+		}
+
+		return c.Status(http.StatusOK).JSON(entries)
+	}
+}
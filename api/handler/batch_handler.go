@@ -0,0 +1,152 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api/dto"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/api/validation"
+	"github.com/gringolito/dnsmasq-manager/pkg/host"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+// Error messages
+const (
+	BatchNotAppliedMessage = "The batch was not applied."
+)
+
+// Details
+const (
+	BatchCouldNotBeParsed = "The request could not be processed because the batch could not be parsed. Please check the request and try again."
+	OperationNotApplied   = "The operation was not applied because another operation of the batch failed."
+	InvalidOperation      = "The operation is invalid. Updates and removals must target a host by its MAC address, IP address or host name."
+)
+
+func BatchStaticHosts(service host.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		batch := new(dto.StaticDhcpHostBatch)
+		if err := c.BodyParser(batch); err != nil {
+			slog.Debug("Failed to parse batch from the body",
+				slog.String("error", err.Error()),
+			)
+			return presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, BatchCouldNotBeParsed)
+		}
+
+		if errors := validation.Validate(batch); errors != nil {
+			return presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, errors)
+		}
+
+		operations := make([]host.Operation, 0, len(batch.Operations))
+		for _, operation := range batch.Operations {
+			operations = append(operations, operation.ToModel())
+		}
+
+		var results []host.Result
+		diff, dryRun, err := mutate(c, service, func(service host.Service) (err error) {
+			results, err = service.Batch(operations)
+			return err
+		})
+		if err != nil && !slices.ContainsFunc(results, failedResult) {
+			// Every operation was applied, but the batch could not be written
+			return writeErrorResponse(c, err)
+		}
+
+		status := http.StatusOK
+		response := make([]dto.StaticDhcpHostOperationResult, 0, len(results))
+		for i, result := range results {
+			r := newOperationResult(operations[i], result, err != nil)
+			if r.Status == http.StatusInternalServerError {
+				return presenter.InternalServerErrorResponse(c)
+			}
+			if result.Err != nil && result.Err != host.ErrNotApplied {
+				status = r.Status
+			}
+			response = append(response, r)
+		}
+
+		if err != nil {
+			slog.Debug("Could not apply the batch because one of its operations failed",
+				slog.String("error", err.Error()),
+			)
+			return presenter.ErrorResponse(c, status, BatchNotAppliedMessage, response)
+		}
+		if dryRun {
+			return dryRunResponse(c, diff)
+		}
+
+		auditChange(c, batchBefore(results), batchAfter(operations))
+		return c.Status(http.StatusOK).JSON(response)
+	}
+}
+
+// batchBefore returns the host replaced or removed by each operation, or nil when it added one.
+func batchBefore(results []host.Result) []*dto.StaticDhcpHost {
+	before := make([]*dto.StaticDhcpHost, 0, len(results))
+	for _, result := range results {
+		var h *dto.StaticDhcpHost
+		if result.Previous != nil {
+			h = dto.NewStaticDhcpHost(result.Previous)
+		}
+		before = append(before, h)
+	}
+
+	return before
+}
+
+// batchAfter returns the host written by each operation, or nil when it removed one.
+func batchAfter(operations []host.Operation) []*dto.StaticDhcpHost {
+	after := make([]*dto.StaticDhcpHost, 0, len(operations))
+	for _, operation := range operations {
+		var h *dto.StaticDhcpHost
+		if operation.Action != host.ActionRemove {
+			h = dto.NewStaticDhcpHost(operation.Host)
+		}
+		after = append(after, h)
+	}
+
+	return after
+}
+
+func failedResult(result host.Result) bool {
+	return result.Err != nil
+}
+
+func newOperationResult(operation host.Operation, result host.Result, failed bool) dto.StaticDhcpHostOperationResult {
+	r := dto.StaticDhcpHostOperationResult{
+		Operation: string(operation.Action),
+	}
+
+	switch {
+	case result.Err == nil && failed, result.Err == host.ErrNotApplied:
+		r.Status = http.StatusFailedDependency
+		r.Error = OperationNotApplied
+	case result.Err == host.ErrInvalidOperation:
+		r.Status = http.StatusUnprocessableEntity
+		r.Error = InvalidOperation
+	case result.Err == host.ErrPreconditionFailed:
+		r.Status = http.StatusPreconditionFailed
+		r.Error = ETagMismatch
+	case result.Err != nil:
+		if e, ok := result.Err.(host.DuplicatedEntryError); ok {
+			r.Status = http.StatusConflict
+			r.Error = e.Error()
+		} else {
+			r.Status = http.StatusInternalServerError
+		}
+	case operation.Action == host.ActionRemove && result.Previous == nil:
+		r.Status = http.StatusNoContent
+	case operation.Action == host.ActionRemove:
+		r.Status = http.StatusOK
+		r.Host = dto.NewStaticDhcpHost(result.Previous)
+	case result.Previous == nil:
+		r.Status = http.StatusCreated
+		r.Host = dto.NewStaticDhcpHost(operation.Host)
+	default:
+		r.Status = http.StatusOK
+		r.Host = dto.NewStaticDhcpHost(operation.Host)
+	}
+
+	return r
+}
@@ -0,0 +1,25 @@
+package handler
+
+import (
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/pkg/host"
+)
+
+// hostChangesKey is the key of the static hosts changed by a request, in its locals
+const hostChangesKey = "changes.hosts"
+
+// hostsChanged records the static hosts changed by the request, to be published once it is done.
+func hostsChanged(c *fiber.Ctx, changes ...host.Change) {
+	if len(changes) == 0 {
+		return
+	}
+
+	recorded, _ := c.Locals(hostChangesKey).([]host.Change)
+	c.Locals(hostChangesKey, append(recorded, changes...))
+}
+
+// HostChanges returns the static hosts changed by the request.
+func HostChanges(c *fiber.Ctx) []host.Change {
+	changes, _ := c.Locals(hostChangesKey).([]host.Change)
+	return changes
+}
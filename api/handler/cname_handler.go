@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api/dto"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/api/validation"
+	"github.com/gringolito/dnsmasq-manager/pkg/cname"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"golang.org/x/exp/slog"
+)
+
+// Error messages
+const (
+	CnameNotFoundMessage      = "No CNAME alias found for the given name."
+	DuplicatedAliasMessage    = "The alias is already in use."
+	UnknownCnameTargetMessage = "The CNAME target is unknown."
+	CnameLoopMessage          = "The CNAME aliases would form a loop."
+)
+
+// Details
+const (
+	NoMatchingAlias = "The DNS server could not find a CNAME that matches the given alias. " +
+		"The alias that was provided was: %s."
+	AliasAlreadyInUse   = "The alias that was provided is already in use by another CNAME: %s."
+	CnameTargetNotFound = "The target must be the host name of a static host, a name of a host-record, or another " +
+		"alias leading to one of them. The target that was provided was: %s."
+	CnameLoop = "The target that was provided leads back to an alias of the CNAME, or through a loop of " +
+		"other aliases: %s."
+	CnameCouldNotBeParsed = "The request could not be processed because the CNAME could not be parsed. " +
+		"Please check the request and try again."
+)
+
+func getCnameFromBody(c *fiber.Ctx) *model.Cname {
+	body := new(dto.Cname)
+	if err := c.BodyParser(body); err != nil {
+		slog.Debug("Failed to parse CNAME from the body",
+			slog.String("error", err.Error()),
+		)
+		presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, CnameCouldNotBeParsed)
+		return nil
+	}
+
+	if errors := validation.Validate(body); errors != nil {
+		presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, errors)
+		return nil
+	}
+
+	return body.ToModel()
+}
+
+func toCnamesDto(cnames *[]model.Cname) *[]dto.Cname {
+	response := make([]dto.Cname, 0, len(*cnames))
+	for _, cn := range *cnames {
+		response = append(response, *dto.NewCname(&cn))
+	}
+
+	return &response
+}
+
+func GetAllCnames(service cname.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cnames, err := service.FetchAll()
+		if err != nil {
+			return presenter.InternalServerErrorResponse(c)
+		}
+
+		return c.Status(http.StatusOK).JSON(toCnamesDto(cnames))
+	}
+}
+
+func GetCname(service cname.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		alias := c.Params("alias")
+		cn, err := service.FetchByAlias(alias)
+		if err != nil {
+			return presenter.InternalServerErrorResponse(c)
+		}
+		if cn == nil {
+			return presenter.NotFoundResponse(c, CnameNotFoundMessage, fmt.Sprintf(NoMatchingAlias, alias))
+		}
+
+		return c.Status(http.StatusOK).JSON(dto.NewCname(cn))
+	}
+}
+
+func AddCname(service cname.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cn := getCnameFromBody(c)
+		if cn == nil {
+			// The errors was already handled by the getCnameFromBody()
+			return nil
+		}
+
+		if err := service.Insert(cn); err != nil {
+			slog.Debug("Could not add a new CNAME",
+				slog.Any("cname", cn),
+				slog.String("error", err.Error()),
+			)
+			return cnameErrorResponse(c, err)
+		}
+
+		auditChange(c, nil, dto.NewCname(cn))
+		return c.Status(http.StatusCreated).JSON(dto.NewCname(cn))
+	}
+}
+
+func UpdateCname(service cname.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cn := getCnameFromBody(c)
+		if cn == nil {
+			// The errors was already handled by the getCnameFromBody()
+			return nil
+		}
+
+		current, err := service.UpdateByAlias(c.Params("alias"), cn)
+		if err != nil {
+			slog.Debug("Could not update the CNAME",
+				slog.Any("cname", cn),
+				slog.String("error", err.Error()),
+			)
+			return cnameErrorResponse(c, err)
+		}
+
+		if current == nil {
+			auditChange(c, nil, dto.NewCname(cn))
+			return c.Status(http.StatusCreated).JSON(dto.NewCname(cn))
+		}
+
+		auditChange(c, dto.NewCname(current), dto.NewCname(cn))
+		return c.Status(http.StatusOK).JSON(dto.NewCname(cn))
+	}
+}
+
+func cnameErrorResponse(c *fiber.Ctx, err error) error {
+	switch e := err.(type) {
+	case cname.DuplicatedEntryError:
+		return presenter.ConflictResponse(c, DuplicatedAliasMessage, fmt.Sprintf(AliasAlreadyInUse, e.Alias))
+	case cname.LoopError:
+		return presenter.ConflictResponse(c, CnameLoopMessage, fmt.Sprintf(CnameLoop, e.Alias))
+	case cname.UnknownTargetError:
+		return presenter.UnprocessableEntityResponse(c, UnknownCnameTargetMessage, fmt.Sprintf(CnameTargetNotFound, e.Target))
+	default:
+		return writeErrorResponse(c, err)
+	}
+}
+
+func RemoveCname(service cname.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cn, err := service.RemoveByAlias(c.Params("alias"))
+		if err != nil {
+			return writeErrorResponse(c, err)
+		}
+		if cn == nil {
+			return c.SendStatus(http.StatusNoContent)
+		}
+
+		auditChange(c, dto.NewCname(cn), nil)
+		return c.Status(http.StatusOK).JSON(dto.NewCname(cn))
+	}
+}
@@ -0,0 +1,163 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api/actor"
+	"github.com/gringolito/dnsmasq-manager/api/dto"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/pkg/git"
+	"github.com/gringolito/dnsmasq-manager/pkg/host"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+// Error messages
+const (
+	CommitNotFoundMessage      = "No commit found for the given hash."
+	RevertConflictMessage      = "The commit could not be reverted."
+	RevertNotAuthorizedMessage = "You do not have permission to revert this commit."
+)
+
+// Details
+const (
+	NoMatchingCommit = "The server could not find a commit changing the managed files with the given hash. " +
+		"The hash that was provided was: %s."
+	RevertConflict = "The changes of the commit conflict with the later changes of the file: %s. " +
+		"Please revert the later commits first."
+	MissingRevertScope = "The commit changed the file %s, which requires one of the scopes: %s."
+	InvalidLimit       = "The `limit` query parameter must be a non-negative integer."
+)
+
+// RevertTarget is a managed file that can be restored when reverting a commit, by the actors
+// holding any of the scopes.
+type RevertTarget struct {
+	Path    string
+	Scopes  []string
+	Restore func(data []byte) error
+	// HostChanges, when set, returns the static hosts changed between two versions of the file
+	HostChanges func(before []byte, after []byte) ([]host.Change, error)
+}
+
+func GetAllCommits(repository *git.Repository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		limit := 0
+		if value := c.Query("limit"); value != "" {
+			var err error
+			if limit, err = strconv.Atoi(value); err != nil || limit < 0 {
+				return presenter.BadRequestResponse(c, InvalidQueryMessage, InvalidLimit)
+			}
+		}
+
+		commits, err := repository.Log(limit)
+		if err != nil {
+			return presenter.InternalServerErrorResponse(c)
+		}
+
+		response := make([]dto.Commit, 0, len(commits))
+		for _, commit := range commits {
+			response = append(response, *dto.NewCommit(&commit))
+		}
+
+		return c.Status(http.StatusOK).JSON(response)
+	}
+}
+
+func GetCommit(repository *git.Repository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		hash := c.Params("hash")
+		commit, diff, err := repository.Show(hash)
+		if err == git.ErrCommitNotFound {
+			return presenter.NotFoundResponse(c, CommitNotFoundMessage, fmt.Sprintf(NoMatchingCommit, hash))
+		}
+		if err != nil {
+			return presenter.InternalServerErrorResponse(c)
+		}
+
+		return c.Status(http.StatusOK).JSON(dto.NewCommitContent(commit, diff))
+	}
+}
+
+// RevertCommit undoes the changes of the commit on top of the current files, each file being
+// restored through the service managing it. The revert is committed as any other change.
+func RevertCommit(repository *git.Repository, targets []RevertTarget) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		hash := c.Params("hash")
+		commit, _, err := repository.Show(hash)
+		if err == git.ErrCommitNotFound {
+			return presenter.NotFoundResponse(c, CommitNotFoundMessage, fmt.Sprintf(NoMatchingCommit, hash))
+		}
+		if err != nil {
+			return presenter.InternalServerErrorResponse(c)
+		}
+
+		// Check every file before restoring any of them
+		a := actor.FromContext(c)
+		for _, file := range commit.Files {
+			target := findRevertTarget(targets, repository.Abs(file))
+			if target == nil {
+				slog.Error("No service manages the file of the commit", slog.String("file", file))
+				return presenter.InternalServerErrorResponse(c)
+			}
+			if !a.HasAnyScope(target.Scopes...) {
+				return presenter.ForbiddenResponse(c, RevertNotAuthorizedMessage,
+					fmt.Sprintf(MissingRevertScope, file, strings.Join(target.Scopes, ", ")))
+			}
+		}
+
+		// The audited values are the contents of the files restored by the revert
+		before, after := map[string]string{}, map[string]string{}
+		err = repository.Revert(commit.Hash, func(path string, data []byte) error {
+			// The commit may have created the file, which was removed since
+			current, err := os.ReadFile(path)
+			if err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return err
+			}
+			before[path], after[path] = string(current), string(data)
+			return findRevertTarget(targets, path).Restore(data)
+		})
+		if e, ok := err.(git.ConflictError); ok {
+			return presenter.ConflictResponse(c, RevertConflictMessage, fmt.Sprintf(RevertConflict, e.Path))
+		}
+		if err != nil {
+			return writeErrorResponse(c, err)
+		}
+
+		auditChange(c, before, after)
+		for path := range after {
+			target := findRevertTarget(targets, path)
+			if target.HostChanges == nil {
+				continue
+			}
+			changes, err := target.HostChanges([]byte(before[path]), []byte(after[path]))
+			if err != nil {
+				slog.Error("Error reading the static hosts changed by the revert",
+					slog.String("file", path),
+					slog.String("error", err.Error()),
+				)
+				continue
+			}
+			hostsChanged(c, changes...)
+		}
+
+		return c.SendStatus(http.StatusNoContent)
+	}
+}
+
+func findRevertTarget(targets []RevertTarget, path string) *RevertTarget {
+	i := slices.IndexFunc(targets, func(target RevertTarget) bool {
+		return target.Path == path
+	})
+	if i < 0 {
+		return nil
+	}
+
+	return &targets[i]
+}
@@ -0,0 +1,211 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api/dto"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/api/validation"
+	"github.com/gringolito/dnsmasq-manager/pkg/dns"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/query"
+	"golang.org/x/exp/slog"
+)
+
+// Error messages
+const (
+	DnsRecordNotFoundMessage    = "No DNS record found for the given name."
+	InvalidDnsRecordTypeMessage = "The DNS record type is invalid."
+	DuplicatedDnsNameMessage    = "The name is already in use."
+)
+
+// Details
+const (
+	NoMatchingDnsRecord = "The DNS server could not find a %s that matches the given name. " +
+		"This could be because the record does not exist, or because it is of another type. " +
+		"The name that was provided was: %s."
+	UnknownDnsRecordType = "The DNS record type must be one of `host-record`, `address` or `ptr-record`. " +
+		"The type that was provided was: %s."
+	DnsNameAlreadyInUse   = "The name that was provided is already in use by another %s: %s."
+	DnsRecordTypeMismatch = "The type of the DNS record must match the type given in the path: %s. " +
+		"The type that was provided was: %s."
+	DnsRecordCouldNotBeParsed = "The request could not be processed because the DNS record could not be parsed. " +
+		"Please check the request and try again."
+)
+
+func getDnsRecordFromBody(c *fiber.Ctx) *model.DnsRecord {
+	record := new(dto.DnsRecord)
+	if err := c.BodyParser(record); err != nil {
+		slog.Debug("Failed to parse DNS record from the body",
+			slog.String("error", err.Error()),
+		)
+		presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, DnsRecordCouldNotBeParsed)
+		return nil
+	}
+
+	if errors := validation.Validate(record); errors != nil {
+		presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, errors)
+		return nil
+	}
+
+	r := record.ToModel()
+	if err := r.Validate(); err != nil {
+		presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, err.Error())
+		return nil
+	}
+
+	return r
+}
+
+// getDnsRecordType reads the record type from the path, replying with an error when it is unknown.
+func getDnsRecordType(c *fiber.Ctx) (model.DnsRecordType, bool) {
+	recordType := c.Params("type")
+	if !model.IsDnsRecordType(recordType) {
+		presenter.BadRequestResponse(c, InvalidDnsRecordTypeMessage, fmt.Sprintf(UnknownDnsRecordType, recordType))
+		return "", false
+	}
+
+	return model.DnsRecordType(recordType), true
+}
+
+func toDnsRecordsDto(records []model.DnsRecord) *[]dto.DnsRecord {
+	response := make([]dto.DnsRecord, 0, len(records))
+	for _, r := range records {
+		response = append(response, *dto.NewDnsRecord(&r))
+	}
+
+	return &response
+}
+
+func GetAllDnsRecords(service dns.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		q := dns.Query{}
+		if recordType := c.Query("type"); recordType != "" {
+			if !model.IsDnsRecordType(recordType) {
+				return presenter.BadRequestResponse(c, InvalidQueryMessage, fmt.Sprintf(UnknownDnsRecordType, recordType))
+			}
+			q.Type = model.DnsRecordType(recordType)
+		}
+
+		if name := c.Query("name"); name != "" {
+			glob, err := query.ParseHostNameGlob(name)
+			if err != nil {
+				return presenter.BadRequestResponse(c, InvalidQueryMessage, fmt.Sprintf(MalformedHostNameGlob, name))
+			}
+			q.Name = glob
+		}
+
+		records, err := service.FetchAll()
+		if err != nil {
+			return presenter.InternalServerErrorResponse(c)
+		}
+
+		return c.Status(http.StatusOK).JSON(toDnsRecordsDto(dns.Select(*records, q)))
+	}
+}
+
+func GetDnsRecord(service dns.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		recordType, ok := getDnsRecordType(c)
+		if !ok {
+			return nil
+		}
+
+		name := c.Params("name")
+		record, err := service.FetchByName(recordType, name)
+		if err != nil {
+			return presenter.InternalServerErrorResponse(c)
+		}
+		if record == nil {
+			return presenter.NotFoundResponse(c, DnsRecordNotFoundMessage, fmt.Sprintf(NoMatchingDnsRecord, recordType, name))
+		}
+
+		return c.Status(http.StatusOK).JSON(dto.NewDnsRecord(record))
+	}
+}
+
+func AddDnsRecord(service dns.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		record := getDnsRecordFromBody(c)
+		if record == nil {
+			// The errors was already handled by the getDnsRecordFromBody()
+			return nil
+		}
+
+		if err := service.Insert(record); err != nil {
+			if e, ok := err.(dns.DuplicatedEntryError); ok {
+				slog.Debug("Could not add a new DNS record because a conflict was detected",
+					slog.Any("record", record),
+					slog.String("error", err.Error()),
+				)
+				return presenter.ConflictResponse(c, DuplicatedDnsNameMessage, fmt.Sprintf(DnsNameAlreadyInUse, e.Type, e.Name))
+			}
+			return writeErrorResponse(c, err)
+		}
+
+		auditChange(c, nil, dto.NewDnsRecord(record))
+		return c.Status(http.StatusCreated).JSON(dto.NewDnsRecord(record))
+	}
+}
+
+func UpdateDnsRecord(service dns.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		recordType, ok := getDnsRecordType(c)
+		if !ok {
+			return nil
+		}
+
+		record := getDnsRecordFromBody(c)
+		if record == nil {
+			// The errors was already handled by the getDnsRecordFromBody()
+			return nil
+		}
+
+		if record.Type != recordType {
+			return presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage,
+				fmt.Sprintf(DnsRecordTypeMismatch, recordType, record.Type))
+		}
+
+		current, err := service.UpdateByName(recordType, c.Params("name"), record)
+		if err != nil {
+			if e, ok := err.(dns.DuplicatedEntryError); ok {
+				slog.Debug("Could not update the DNS record because a conflict was detected",
+					slog.Any("record", record),
+					slog.String("error", err.Error()),
+				)
+				return presenter.ConflictResponse(c, DuplicatedDnsNameMessage, fmt.Sprintf(DnsNameAlreadyInUse, e.Type, e.Name))
+			}
+			return writeErrorResponse(c, err)
+		}
+
+		if current == nil {
+			auditChange(c, nil, dto.NewDnsRecord(record))
+			return c.Status(http.StatusCreated).JSON(dto.NewDnsRecord(record))
+		}
+
+		auditChange(c, dto.NewDnsRecord(current), dto.NewDnsRecord(record))
+		return c.Status(http.StatusOK).JSON(dto.NewDnsRecord(record))
+	}
+}
+
+func RemoveDnsRecord(service dns.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		recordType, ok := getDnsRecordType(c)
+		if !ok {
+			return nil
+		}
+
+		record, err := service.RemoveByName(recordType, c.Params("name"))
+		if err != nil {
+			return writeErrorResponse(c, err)
+		}
+		if record == nil {
+			return c.SendStatus(http.StatusNoContent)
+		}
+
+		auditChange(c, dto.NewDnsRecord(record), nil)
+		return c.Status(http.StatusOK).JSON(dto.NewDnsRecord(record))
+	}
+}
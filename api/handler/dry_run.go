@@ -0,0 +1,29 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/pkg/host"
+)
+
+const diffContentType = "text/x-diff; charset=utf-8"
+
+// mutate runs fn against the service, or against a dry run of it when the request sets the dryRun
+// query parameter. The unified diff of the changes is only returned by the dry runs, while the
+// static hosts changed by the other runs are recorded into the request.
+func mutate(c *fiber.Ctx, service host.Service, fn func(host.Service) error) (diff string, dryRun bool, err error) {
+	if !c.QueryBool("dryRun") {
+		changes, err := service.Observe(fn)
+		hostsChanged(c, changes...)
+		return "", false, err
+	}
+
+	diff, err = service.DryRun(fn)
+	return diff, true, err
+}
+
+func dryRunResponse(c *fiber.Ctx, diff string) error {
+	c.Set(fiber.HeaderContentType, diffContentType)
+	return c.Status(http.StatusOK).SendString(diff)
+}
@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/pkg/host"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// hostETag is a strong entity tag of the dnsmasq entry of the host.
+func hostETag(h *model.StaticDhcpHost) string {
+	return newETag(h.ToConfig())
+}
+
+// hostsETag is a strong entity tag of the dnsmasq entries of all the hosts.
+func hostsETag(hosts *[]model.StaticDhcpHost) string {
+	entries := make([]string, 0, len(*hosts))
+	for _, h := range *hosts {
+		entries = append(entries, h.ToConfig())
+	}
+
+	return newETag(strings.Join(entries, "\n"))
+}
+
+func newETag(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// notModified reports whether the If-None-Match request header matches the given entity tag.
+// As required by RFC 9110, the comparison is weak.
+func notModified(c *fiber.Ctx, etag string) bool {
+	ifNoneMatch := c.Get(fiber.HeaderIfNoneMatch)
+	if ifNoneMatch == "" {
+		return false
+	}
+
+	for _, tag := range strings.Split(ifNoneMatch, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || strings.TrimPrefix(tag, "W/") == etag {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ifMatch returns the precondition that rejects changes to a host whose entity tag does not match
+// the If-Match request header, if any. As required by RFC 9110, the comparison is strong.
+func ifMatch(c *fiber.Ctx) host.Precondition {
+	header := c.Get(fiber.HeaderIfMatch)
+	if header == "" {
+		return nil
+	}
+
+	tags := strings.Split(header, ",")
+	for i := range tags {
+		tags[i] = strings.TrimSpace(tags[i])
+	}
+
+	return func(current *model.StaticDhcpHost) error {
+		if current == nil {
+			return host.ErrPreconditionFailed
+		}
+
+		etag := hostETag(current)
+		for _, tag := range tags {
+			if tag == "*" || tag == etag {
+				return nil
+			}
+		}
+
+		return host.ErrPreconditionFailed
+	}
+}
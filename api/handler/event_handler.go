@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api/actor"
+	"github.com/gringolito/dnsmasq-manager/pkg/event"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	eventStreamContentType = "text/event-stream"
+	lastEventIDHeader      = "Last-Event-ID"
+	// keepAliveInterval is the time between the comments keeping an idle stream open, which also
+	// find out the clients that went away
+	keepAliveInterval = 15 * time.Second
+	// reconnectDelay is the time the clients wait for before reconnecting to a closed stream
+	reconnectDelay = 3 * time.Second
+)
+
+// StreamEvents streams the events the actor is allowed to read as server-sent events. A client
+// resuming the stream with the Last-Event-ID header first receives the buffered events it missed.
+func StreamEvents(broker *event.Broker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		a := actor.FromContext(c)
+		missed, events, cancel := broker.Subscribe(c.Get(lastEventIDHeader))
+
+		c.Set(fiber.HeaderContentType, eventStreamContentType)
+		c.Set(fiber.HeaderCacheControl, "no-cache")
+		c.Set(fiber.HeaderConnection, "keep-alive")
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer cancel()
+
+			// Sends the headers straight away, even without any event
+			if _, err := fmt.Fprintf(w, "retry: %d\n\n", reconnectDelay.Milliseconds()); err != nil {
+				return
+			}
+			for _, e := range missed {
+				if err := writeEvent(w, a, e); err != nil {
+					return
+				}
+			}
+			if err := w.Flush(); err != nil {
+				return
+			}
+
+			ticker := time.NewTicker(keepAliveInterval)
+			defer ticker.Stop()
+			for {
+				select {
+				case e, ok := <-events:
+					if !ok {
+						// The client fell too far behind, it may resume from its last event
+						return
+					}
+					if err := writeEvent(w, a, e); err != nil {
+						return
+					}
+				case <-ticker.C:
+					if _, err := w.WriteString(": keep-alive\n\n"); err != nil {
+						return
+					}
+				}
+				if err := w.Flush(); err != nil {
+					slog.Debug("Event stream closed", slog.String("error", err.Error()))
+					return
+				}
+			}
+		})
+
+		return nil
+	}
+}
+
+// writeEvent writes the event, unless the actor is not allowed to read it.
+func writeEvent(w *bufio.Writer, a actor.Actor, e *event.Event) error {
+	if !a.HasAnyScope(e.Scopes...) {
+		return nil
+	}
+
+	data, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	_, err = fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
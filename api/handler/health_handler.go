@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api/dto"
+	"github.com/gringolito/dnsmasq-manager/pkg/health"
+	"golang.org/x/exp/slog"
+)
+
+// GetLiveness reports that the process is up, it is answering the request after all.
+func GetLiveness() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		return c.Status(http.StatusOK).JSON(dto.Health{Status: health.Pass})
+	}
+}
+
+// GetReadiness runs the readiness checks, failing with 503 when any of them failed.
+func GetReadiness(checker *health.Checker) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		results, ready := checker.Run()
+		if !ready {
+			for _, r := range results {
+				if r.Status == health.Fail {
+					slog.Warn("Readiness check failed",
+						slog.String("check", r.Name),
+						slog.String("error", r.Error),
+					)
+				}
+			}
+			return c.Status(http.StatusServiceUnavailable).JSON(dto.NewHealth(results, ready))
+		}
+
+		return c.Status(http.StatusOK).JSON(dto.NewHealth(results, ready))
+	}
+}
@@ -0,0 +1,117 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api/dto"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/pkg/backup"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/host"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"golang.org/x/exp/slog"
+)
+
+// Error messages
+const (
+	SnapshotNotFoundMessage = "No snapshot found for the given ID."
+)
+
+// Details
+const (
+	NoMatchingSnapshot = "The server could not find a snapshot of the static hosts file with the given ID. " +
+		"This could be because the snapshot never existed, or because it was already removed to keep the latest ones. " +
+		"The ID that was provided was: %s."
+)
+
+// getSnapshot reads the snapshot given in the path, replying with an error when there is none.
+func getSnapshot(c *fiber.Ctx, store *backup.Store) (*backup.Snapshot, []byte) {
+	id := c.Params("id")
+	snapshot, data, err := store.Read(id)
+	if err == backup.ErrSnapshotNotFound {
+		presenter.NotFoundResponse(c, SnapshotNotFoundMessage, fmt.Sprintf(NoMatchingSnapshot, id))
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("Error reading the snapshot",
+			slog.String("id", id),
+			slog.String("error", err.Error()),
+		)
+		presenter.InternalServerErrorResponse(c)
+		return nil, nil
+	}
+
+	return snapshot, data
+}
+
+func GetStaticHistory(store *backup.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		snapshots, err := store.List()
+		if err != nil {
+			return presenter.InternalServerErrorResponse(c)
+		}
+
+		response := make([]dto.Snapshot, 0, len(snapshots))
+		for _, snapshot := range snapshots {
+			response = append(response, *dto.NewSnapshot(&snapshot))
+		}
+
+		return c.Status(http.StatusOK).JSON(response)
+	}
+}
+
+func GetStaticSnapshot(store *backup.Store) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		snapshot, data := getSnapshot(c, store)
+		if snapshot == nil {
+			// The errors was already handled by the getSnapshot()
+			return nil
+		}
+
+		current, err := os.ReadFile(store.Path())
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return presenter.InternalServerErrorResponse(c)
+		}
+
+		diff := dnsmasq.Diff(store.Path(), current, data)
+		return c.Status(http.StatusOK).JSON(dto.NewSnapshotContent(snapshot, data, diff))
+	}
+}
+
+func RestoreStaticSnapshot(store *backup.Store, service host.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		snapshot, data := getSnapshot(c, store)
+		if snapshot == nil {
+			// The errors was already handled by the getSnapshot()
+			return nil
+		}
+
+		var before *[]model.StaticDhcpHost
+		diff, dryRun, err := mutate(c, service, func(service host.Service) (err error) {
+			if before, err = service.FetchAll(); err != nil {
+				return err
+			}
+			return service.Restore(data)
+		})
+		if err != nil {
+			return writeErrorResponse(c, err)
+		}
+		if dryRun {
+			return dryRunResponse(c, diff)
+		}
+
+		hosts, err := service.FetchAll()
+		if err != nil {
+			return presenter.InternalServerErrorResponse(c)
+		}
+
+		auditChange(c, toStaticDhcpHostsDto(before), toStaticDhcpHostsDto(hosts))
+		c.Set(fiber.HeaderETag, hostsETag(hosts))
+		return c.Status(http.StatusOK).JSON(toStaticDhcpHostsDto(hosts))
+	}
+}
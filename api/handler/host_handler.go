@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"net"
 	"net/http"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gringolito/dnsmasq-manager/api/dto"
@@ -20,8 +21,11 @@ const (
 	InvalidRequestMessage       = "The request is invalid."
 	InvalidRequestBodyMessage   = "The request body was invalid."
 	InvalidMacAddressMessage    = "The MAC address is invalid."
+	InvalidIPAddressMessage     = "The IP address is invalid."
 	DuplicatedMacAddressMessage = "A host with the same MAC address already exists."
 	DuplicatedIPAddressMessage  = "The IP address is already in use."
+	DuplicatedHostNameMessage   = "The host name is already in use."
+	PreconditionFailedMessage   = "The static host was modified in the meantime."
 )
 
 // Details
@@ -32,14 +36,23 @@ const (
 	NoMatchingMacAddress = "The DHCP server could not find a static host that matches the given MAC address. " +
 		"This could be because the host does not exist, or because the DHCP server is not configured to statically " +
 		"assign an IP address to the given host. The MAC address that was provided was: %s."
-	MissingQueryParameter = "The request did not specify either the `mac` or `ip` query parameter. " +
+	NoMatchingHostName = "The DHCP server could not find a static host that matches the given host name. " +
+		"This could be because the host does not exist, or because the DHCP server is not configured to statically " +
+		"assign an IP address to the given host. The host name that was provided was: %s."
+	MissingQueryParameter = "The request did not specify either the `mac`, `ip` or `name` query parameter. " +
 		"Please specify one of these parameters in order to proceed."
 	MalformedMacAddress = "The MAC address that was provided is not in the correct format. " +
 		"Please check the format of the MAC address and try again. The MAC address that was provided was: %s."
 	IPAddressAlreadyInUse = "The IP address that was provided is already in use by another host. " +
 		"Please try again with a different IP address. The IP address that was provided was: %s."
+	MalformedIPAddress = "The IP address that was provided is not in the correct format. " +
+		"Please check the format of the IP address and try again. The IP address that was provided was: %s."
 	MacAddressAlreadyInUse = "The MAC address that was provided is already in use by another host: %s."
-	HostCouldNotBeParsed   = "The request could not be processed because the host could not be parsed. Please check the request and try again."
+	HostNameAlreadyInUse   = "The host name that was provided is already in use by another host: %s."
+	ForceReplaceHint       = " Set the `force` query parameter to replace the other host."
+	ETagMismatch           = "The static host does not match the entity tag given in the If-Match header. " +
+		"Please fetch the host again and retry the request with its current entity tag."
+	HostCouldNotBeParsed = "The request could not be processed because the host could not be parsed. Please check the request and try again."
 )
 
 func getHostFromBody(c *fiber.Ctx) *model.StaticDhcpHost {
@@ -60,6 +73,12 @@ func getHostFromBody(c *fiber.Ctx) *model.StaticDhcpHost {
 	return host.ToModel()
 }
 
+// parseIPAddress parses an IPv4 or IPv6 address, also accepting the bracketed form of the IPv6
+// addresses used by dnsmasq.
+func parseIPAddress(ipAddress string) net.IP {
+	return net.ParseIP(strings.TrimSuffix(strings.TrimPrefix(ipAddress, "["), "]"))
+}
+
 func toStaticDhcpHostsDto(hosts *[]model.StaticDhcpHost) *[]dto.StaticDhcpHost {
 	response := make([]dto.StaticDhcpHost, 0, len(*hosts))
 	for _, h := range *hosts {
@@ -71,11 +90,29 @@ func toStaticDhcpHostsDto(hosts *[]model.StaticDhcpHost) *[]dto.StaticDhcpHost {
 
 func GetAllStaticHosts(service host.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		hosts, err := service.FetchAll()
+		q, details := parseQuery(c)
+		if details != "" {
+			return presenter.BadRequestResponse(c, InvalidQueryMessage, details)
+		}
+
+		all, err := service.FetchAll()
 		if err != nil {
 			return presenter.InternalServerErrorResponse(c)
 		}
 
+		page, err := host.Query(*all, q)
+		if err != nil {
+			return presenter.BadRequestResponse(c, InvalidQueryMessage, MalformedCursor)
+		}
+		hosts := &page.Items
+
+		setPaginationHeaders(c, page)
+		etag := hostsETag(hosts)
+		c.Set(fiber.HeaderETag, etag)
+		if notModified(c, etag) {
+			return c.SendStatus(http.StatusNotModified)
+		}
+
 		return c.Status(http.StatusOK).JSON(toStaticDhcpHostsDto(hosts))
 	}
 }
@@ -92,12 +129,17 @@ func GetStaticHost(service host.Service) fiber.Handler {
 			return getStaticHostByIP(service, c, ipAddress)
 		}
 
+		hostName := c.Query("name")
+		if len(hostName) > 0 {
+			return getStaticHostByHostName(service, c, hostName)
+		}
+
 		return presenter.BadRequestResponse(c, InvalidRequestMessage, MissingQueryParameter)
 	}
 }
 
 func getStaticHostByMac(service host.Service, c *fiber.Ctx, macAddress string) error {
-	mac, err := net.ParseMAC(macAddress)
+	mac, err := model.ParseHardwareAddress(macAddress)
 	if err != nil {
 		slog.Debug("Could not parse MAC address",
 			slog.String("macAddress", macAddress),
@@ -114,11 +156,11 @@ func getStaticHostByMac(service host.Service, c *fiber.Ctx, macAddress string) e
 		return presenter.NotFoundResponse(c, StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingMacAddress, macAddress))
 	}
 
-	return c.Status(http.StatusOK).JSON(dto.NewStaticDhcpHost(host))
+	return staticHostResponse(c, host)
 }
 
 func getStaticHostByIP(service host.Service, c *fiber.Ctx, ipAddress string) error {
-	host, err := service.FetchByIP(net.ParseIP(ipAddress))
+	host, err := service.FetchByIP(parseIPAddress(ipAddress))
 	if err != nil {
 		return presenter.InternalServerErrorResponse(c)
 	}
@@ -126,6 +168,28 @@ func getStaticHostByIP(service host.Service, c *fiber.Ctx, ipAddress string) err
 		return presenter.NotFoundResponse(c, StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingIPAddress, ipAddress))
 	}
 
+	return staticHostResponse(c, host)
+}
+
+func getStaticHostByHostName(service host.Service, c *fiber.Ctx, hostName string) error {
+	host, err := service.FetchByHostName(hostName)
+	if err != nil {
+		return presenter.InternalServerErrorResponse(c)
+	}
+	if host == nil {
+		return presenter.NotFoundResponse(c, StaticHostNotFoundMessage, fmt.Sprintf(NoMatchingHostName, hostName))
+	}
+
+	return staticHostResponse(c, host)
+}
+
+func staticHostResponse(c *fiber.Ctx, host *model.StaticDhcpHost) error {
+	etag := hostETag(host)
+	c.Set(fiber.HeaderETag, etag)
+	if notModified(c, etag) {
+		return c.SendStatus(http.StatusNotModified)
+	}
+
 	return c.Status(http.StatusOK).JSON(dto.NewStaticDhcpHost(host))
 }
 
@@ -137,39 +201,114 @@ func AddStaticHost(service host.Service) fiber.Handler {
 			return nil
 		}
 
-		if err := service.Insert(h); err != nil {
+		diff, dryRun, err := mutate(c, service, func(service host.Service) error {
+			return service.Insert(h)
+		})
+		if err != nil {
 			if e, ok := err.(host.DuplicatedEntryError); ok {
 				slog.Debug("Could not add a new static host because a conflict was detected",
 					slog.Any("host", h),
 					slog.String("error", err.Error()),
 				)
-				if e.Field == "IP" {
-					return presenter.ConflictResponse(c, DuplicatedIPAddressMessage, fmt.Sprintf(IPAddressAlreadyInUse, h.IPAddress.String()))
-				} else {
-					return presenter.ConflictResponse(c, DuplicatedMacAddressMessage, fmt.Sprintf(MacAddressAlreadyInUse, h.MacAddress.String()))
-				}
+				return duplicatedEntryResponse(c, e, "")
 			} else {
-				return presenter.InternalServerErrorResponse(c)
+				return writeErrorResponse(c, err)
 			}
 		}
+		if dryRun {
+			return dryRunResponse(c, diff)
+		}
 
+		auditChange(c, nil, dto.NewStaticDhcpHost(h))
+		c.Set(fiber.HeaderETag, hostETag(h))
 		return c.Status(http.StatusCreated).JSON(dto.NewStaticDhcpHost(h))
 	}
 }
 
 func UpdateStaticHost(service host.Service) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		host := getHostFromBody(c)
-		if host == nil {
+		h := getHostFromBody(c)
+		if h == nil {
 			// The errors was already handled by the getHostFromBody()
 			return nil
 		}
 
-		if err := service.Update(host); err != nil {
-			return presenter.InternalServerErrorResponse(c)
+		options := host.Options{
+			Force:        c.QueryBool("force"),
+			Precondition: ifMatch(c),
+		}
+
+		var update func(service host.Service) (*model.StaticDhcpHost, error)
+		if macAddress := c.Query("mac"); len(macAddress) > 0 {
+			mac, parseErr := model.ParseHardwareAddress(macAddress)
+			if parseErr != nil {
+				return presenter.BadRequestResponse(c, InvalidMacAddressMessage, fmt.Sprintf(MalformedMacAddress, macAddress))
+			}
+			update = func(service host.Service) (*model.StaticDhcpHost, error) {
+				return service.UpdateByMac(mac, h, options)
+			}
+		} else if ipAddress := c.Query("ip"); len(ipAddress) > 0 {
+			ip := parseIPAddress(ipAddress)
+			if ip == nil {
+				return presenter.BadRequestResponse(c, InvalidIPAddressMessage, fmt.Sprintf(MalformedIPAddress, ipAddress))
+			}
+			update = func(service host.Service) (*model.StaticDhcpHost, error) {
+				return service.UpdateByIP(ip, h, options)
+			}
+		} else if hostName := c.Query("name"); len(hostName) > 0 {
+			update = func(service host.Service) (*model.StaticDhcpHost, error) {
+				return service.UpdateByHostName(hostName, h, options)
+			}
+		} else if len(h.MacAddresses) > 0 {
+			// Without an explicit identifier, the host is targeted by its own (first) MAC address
+			update = func(service host.Service) (*model.StaticDhcpHost, error) {
+				return service.UpdateByMac(h.MacAddresses[0], h, options)
+			}
+		} else {
+			return presenter.BadRequestResponse(c, InvalidRequestMessage, MissingQueryParameter)
 		}
 
-		return c.Status(http.StatusCreated).JSON(dto.NewStaticDhcpHost(host))
+		var current *model.StaticDhcpHost
+		diff, dryRun, err := mutate(c, service, func(service host.Service) (err error) {
+			current, err = update(service)
+			return err
+		})
+		if err != nil {
+			if e, ok := err.(host.DuplicatedEntryError); ok {
+				slog.Debug("Could not update the static host because a conflict was detected",
+					slog.Any("host", h),
+					slog.String("error", err.Error()),
+				)
+				return duplicatedEntryResponse(c, e, ForceReplaceHint)
+			}
+			if err == host.ErrPreconditionFailed {
+				return presenter.PreconditionFailedResponse(c, PreconditionFailedMessage, ETagMismatch)
+			}
+			return writeErrorResponse(c, err)
+		}
+		if dryRun {
+			return dryRunResponse(c, diff)
+		}
+
+		c.Set(fiber.HeaderETag, hostETag(h))
+		if current == nil {
+			auditChange(c, nil, dto.NewStaticDhcpHost(h))
+			return c.Status(http.StatusCreated).JSON(dto.NewStaticDhcpHost(h))
+		}
+
+		auditChange(c, dto.NewStaticDhcpHost(current), dto.NewStaticDhcpHost(h))
+		return c.Status(http.StatusOK).JSON(dto.NewStaticDhcpHost(h))
+	}
+}
+
+func duplicatedEntryResponse(c *fiber.Ctx, e host.DuplicatedEntryError, hint string) error {
+	switch e.Field {
+	case "IP":
+		return presenter.ConflictResponse(c, DuplicatedIPAddressMessage, fmt.Sprintf(IPAddressAlreadyInUse, e.Value)+hint)
+	case "HostName":
+		return presenter.ConflictResponse(c, DuplicatedHostNameMessage, fmt.Sprintf(HostNameAlreadyInUse, e.Value)+hint)
+	default:
+		return presenter.ConflictResponse(c, DuplicatedMacAddressMessage, fmt.Sprintf(MacAddressAlreadyInUse, e.Value)+hint)
 	}
 }
 
@@ -185,12 +324,17 @@ func RemoveStaticHost(service host.Service) fiber.Handler {
 			return removeStaticHostByIP(service, c, ipAddress)
 		}
 
+		hostName := c.Query("name")
+		if len(hostName) > 0 {
+			return removeStaticHostByHostName(service, c, hostName)
+		}
+
 		return presenter.BadRequestResponse(c, InvalidRequestMessage, MissingQueryParameter)
 	}
 }
 
 func removeStaticHostByMac(service host.Service, c *fiber.Ctx, macAddress string) error {
-	mac, err := net.ParseMAC(macAddress)
+	mac, err := model.ParseHardwareAddress(macAddress)
 	if err != nil {
 		slog.Debug("Could not parse MAC address",
 			slog.String("macAddress", macAddress),
@@ -199,25 +343,69 @@ func removeStaticHostByMac(service host.Service, c *fiber.Ctx, macAddress string
 		return presenter.BadRequestResponse(c, InvalidMacAddressMessage, fmt.Sprintf(MalformedMacAddress, macAddress))
 	}
 
-	host, err := service.RemoveByMac(mac)
+	var h *model.StaticDhcpHost
+	diff, dryRun, err := mutate(c, service, func(service host.Service) (err error) {
+		h, err = service.RemoveByMac(mac, host.Options{Precondition: ifMatch(c)})
+		return err
+	})
 	if err != nil {
-		return presenter.InternalServerErrorResponse(c)
+		return removeErrorResponse(c, err)
 	}
-	if host == nil {
+	if dryRun {
+		return dryRunResponse(c, diff)
+	}
+	if h == nil {
 		return c.SendStatus(http.StatusNoContent)
 	}
 
-	return c.Status(http.StatusOK).JSON(dto.NewStaticDhcpHost(host))
+	auditChange(c, dto.NewStaticDhcpHost(h), nil)
+	return c.Status(http.StatusOK).JSON(dto.NewStaticDhcpHost(h))
 }
 
 func removeStaticHostByIP(service host.Service, c *fiber.Ctx, ipAddress string) error {
-	host, err := service.RemoveByIP(net.ParseIP(ipAddress))
+	var h *model.StaticDhcpHost
+	diff, dryRun, err := mutate(c, service, func(service host.Service) (err error) {
+		h, err = service.RemoveByIP(parseIPAddress(ipAddress), host.Options{Precondition: ifMatch(c)})
+		return err
+	})
 	if err != nil {
-		return presenter.InternalServerErrorResponse(c)
+		return removeErrorResponse(c, err)
 	}
-	if host == nil {
+	if dryRun {
+		return dryRunResponse(c, diff)
+	}
+	if h == nil {
 		return c.SendStatus(http.StatusNoContent)
 	}
 
-	return c.Status(http.StatusOK).JSON(dto.NewStaticDhcpHost(host))
+	auditChange(c, dto.NewStaticDhcpHost(h), nil)
+	return c.Status(http.StatusOK).JSON(dto.NewStaticDhcpHost(h))
+}
+
+func removeStaticHostByHostName(service host.Service, c *fiber.Ctx, hostName string) error {
+	var h *model.StaticDhcpHost
+	diff, dryRun, err := mutate(c, service, func(service host.Service) (err error) {
+		h, err = service.RemoveByHostName(hostName, host.Options{Precondition: ifMatch(c)})
+		return err
+	})
+	if err != nil {
+		return removeErrorResponse(c, err)
+	}
+	if dryRun {
+		return dryRunResponse(c, diff)
+	}
+	if h == nil {
+		return c.SendStatus(http.StatusNoContent)
+	}
+
+	auditChange(c, dto.NewStaticDhcpHost(h), nil)
+	return c.Status(http.StatusOK).JSON(dto.NewStaticDhcpHost(h))
+}
+
+func removeErrorResponse(c *fiber.Ctx, err error) error {
+	if err == host.ErrPreconditionFailed {
+		return presenter.PreconditionFailedResponse(c, PreconditionFailedMessage, ETagMismatch)
+	}
+
+	return writeErrorResponse(c, err)
 }
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api/dto"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/api/validation"
+	"github.com/gringolito/dnsmasq-manager/pkg/host"
+	"github.com/gringolito/dnsmasq-manager/pkg/lease"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"golang.org/x/exp/slog"
+)
+
+// Error messages
+const (
+	LeaseNotFoundMessage = "No DHCP lease found for the given MAC address."
+)
+
+// Details
+const (
+	NoMatchingLease = "The DHCP server could not find a lease held by the given MAC address. " +
+		"This could be because the lease expired, or because the client was never given one. " +
+		"The MAC address that was provided was: %s."
+	PromotionCouldNotBeParsed = "The request could not be processed because the overrides could not be parsed. " +
+		"Please check the request and try again."
+)
+
+func toLeasesDto(leases *[]model.Lease) *[]dto.Lease {
+	response := make([]dto.Lease, 0, len(*leases))
+	for _, l := range *leases {
+		response = append(response, *dto.NewLease(&l))
+	}
+
+	return &response
+}
+
+func GetAllLeases(service lease.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		q, details := parseQuery(c)
+		if details != "" {
+			return presenter.BadRequestResponse(c, InvalidQueryMessage, details)
+		}
+
+		all, err := service.FetchAll()
+		if err != nil {
+			return presenter.InternalServerErrorResponse(c)
+		}
+
+		page, err := lease.Query(*all, q)
+		if err != nil {
+			return presenter.BadRequestResponse(c, InvalidQueryMessage, MalformedCursor)
+		}
+
+		setPaginationHeaders(c, page)
+		return c.Status(http.StatusOK).JSON(toLeasesDto(&page.Items))
+	}
+}
+
+func PromoteLease(service lease.Service) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		macAddress := c.Params("mac")
+		mac, err := model.ParseHardwareAddress(macAddress)
+		if err != nil {
+			return presenter.BadRequestResponse(c, InvalidMacAddressMessage, fmt.Sprintf(MalformedMacAddress, macAddress))
+		}
+
+		// The overrides are optional, as is the whole body
+		overrides := new(dto.LeasePromotion)
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(overrides); err != nil {
+				return presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, PromotionCouldNotBeParsed)
+			}
+			if errors := validation.Validate(overrides); errors != nil {
+				return presenter.UnprocessableEntityResponse(c, InvalidRequestBodyMessage, errors)
+			}
+		}
+
+		h, err := service.Promote(mac, net.ParseIP(overrides.IPAddress), overrides.HostName)
+		if err != nil {
+			if e, ok := err.(host.DuplicatedEntryError); ok {
+				slog.Debug("Could not promote the lease because a conflict was detected",
+					slog.String("macAddress", macAddress),
+					slog.String("error", err.Error()),
+				)
+				return duplicatedEntryResponse(c, e, "")
+			}
+			if err == lease.ErrLeaseNotFound {
+				return presenter.NotFoundResponse(c, LeaseNotFoundMessage, fmt.Sprintf(NoMatchingLease, macAddress))
+			}
+			return writeErrorResponse(c, err)
+		}
+
+		auditChange(c, nil, dto.NewStaticDhcpHost(h))
+		hostsChanged(c, host.Change{After: h})
+		c.Set(fiber.HeaderETag, hostETag(h))
+		return c.Status(http.StatusCreated).JSON(dto.NewStaticDhcpHost(h))
+	}
+}
@@ -0,0 +1,111 @@
+package handler
+
+import (
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/pkg/query"
+)
+
+// Error messages
+const (
+	InvalidQueryMessage = "The query is invalid."
+)
+
+// Details
+const (
+	MalformedSubnet = "The subnet that was provided is not in the CIDR notation (e.g. 192.168.0.0/24). " +
+		"The subnet that was provided was: %s."
+	MalformedHostNameGlob  = "The host name pattern that was provided is not a valid glob pattern: %s."
+	MalformedHostNameRegex = "The host name regular expression that was provided is invalid: %s."
+	MalformedVendor        = "The MAC vendor prefix that was provided is not in the correct format (e.g. 00:1a:2b). " +
+		"The MAC vendor prefix that was provided was: %s."
+	InvalidSortField = "The sort field must be one of `ip`, `mac` or `hostname`, optionally prefixed by `-` for " +
+		"a descending order. The sort field that was provided was: %s."
+	InvalidPagination = "The `limit` and `offset` query parameters must be non-negative integers, and `offset` " +
+		"cannot be combined with `cursor`."
+	MalformedCursor = "The cursor that was provided is invalid. Please use the cursor returned by the previous page."
+)
+
+// Pagination headers
+const (
+	HeaderTotalCount = "X-Total-Count"
+	HeaderNextCursor = "X-Next-Cursor"
+)
+
+// parseQuery reads the filtering, sorting and pagination query parameters. On failure, it returns
+// the details of what is wrong with them.
+func parseQuery(c *fiber.Ctx) (query.Query, string) {
+	q := query.Query{}
+
+	if subnet := c.Query("subnet"); subnet != "" {
+		_, ipNet, err := net.ParseCIDR(subnet)
+		if err != nil {
+			return q, fmt.Sprintf(MalformedSubnet, subnet)
+		}
+		q.Subnet = ipNet
+	}
+
+	if name := c.Query("name"); name != "" {
+		glob, err := query.ParseHostNameGlob(name)
+		if err != nil {
+			return q, fmt.Sprintf(MalformedHostNameGlob, name)
+		}
+		q.HostName = glob
+	}
+
+	if nameRegex := c.Query("nameRegex"); nameRegex != "" {
+		re, err := regexp.Compile(nameRegex)
+		if err != nil {
+			return q, fmt.Sprintf(MalformedHostNameRegex, nameRegex)
+		}
+		q.HostNameRegexp = re
+	}
+
+	if vendor := c.Query("vendor"); vendor != "" {
+		prefix, err := query.ParseVendor(vendor)
+		if err != nil {
+			return q, fmt.Sprintf(MalformedVendor, vendor)
+		}
+		q.Vendor = prefix
+	}
+
+	if sort := c.Query("sort"); sort != "" {
+		field, descending, err := query.ParseSort(sort)
+		if err != nil {
+			return q, fmt.Sprintf(InvalidSortField, sort)
+		}
+		q.SortBy = field
+		q.Descending = descending
+	}
+
+	var err error
+	if limit := c.Query("limit"); limit != "" {
+		if q.Limit, err = strconv.Atoi(limit); err != nil || q.Limit < 0 {
+			return q, InvalidPagination
+		}
+	}
+
+	if offset := c.Query("offset"); offset != "" {
+		if q.Offset, err = strconv.Atoi(offset); err != nil || q.Offset < 0 {
+			return q, InvalidPagination
+		}
+	}
+
+	q.Cursor = c.Query("cursor")
+	if q.Cursor != "" && q.Offset > 0 {
+		return q, InvalidPagination
+	}
+
+	return q, ""
+}
+
+func setPaginationHeaders[T any](c *fiber.Ctx, page *query.Page[T]) {
+	c.Set(HeaderTotalCount, strconv.Itoa(page.Total))
+	if page.NextCursor != "" {
+		c.Set(HeaderNextCursor, page.NextCursor)
+	}
+}
@@ -0,0 +1,43 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"golang.org/x/exp/slog"
+)
+
+// Error messages
+const (
+	InvalidConfigurationMessage = "The change was rejected by the configuration validator."
+	NameInUseMessage            = "The name is still in use."
+)
+
+// Details
+const (
+	NameTargetedByAlias = "The change removes or renames a name that is the target of a CNAME alias, which dnsmasq " +
+		"would refuse: %s. Please remove or retarget the alias first: %s."
+)
+
+// writeErrorResponse replies to the errors that are not specific to the resource, when writing the
+// configuration files.
+func writeErrorResponse(c *fiber.Ctx, err error) error {
+	var e dnsmasq.ValidationError
+	if errors.As(err, &e) {
+		slog.Debug("The configuration file was rejected by the validator",
+			slog.String("file", e.Path),
+			slog.String("output", e.Output),
+		)
+		return presenter.UnprocessableEntityResponse(c, InvalidConfigurationMessage, e.Output)
+	}
+
+	var inUse dnsmasq.NameInUseError
+	if errors.As(err, &inUse) {
+		return presenter.ConflictResponse(c, NameInUseMessage, fmt.Sprintf(NameTargetedByAlias, inUse.Name, inUse.Entry))
+	}
+
+	return presenter.InternalServerErrorResponse(c)
+}
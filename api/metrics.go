@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/utils"
+	"github.com/gringolito/dnsmasq-manager/pkg/metrics"
+)
+
+// routeNames keeps the name of every route, by its method and path. Fiber names every route sharing
+// the path of the named one, whatever its method, so the names are rather taken from the OnName
+// hook, which only receives the named route.
+type routeNames struct {
+	mutex sync.RWMutex
+	names map[string]string
+}
+
+func newRouteNames(app *fiber.App) *routeNames {
+	n := &routeNames{names: map[string]string{}}
+	app.Hooks().OnName(func(route fiber.Route) error {
+		n.mutex.Lock()
+		defer n.mutex.Unlock()
+
+		n.names[route.Method+" "+route.Path] = route.Name
+		return nil
+	})
+
+	return n
+}
+
+// lookup returns the name of the route, or its path when it has none. The HEAD routes are named
+// after their GET route.
+func (n *routeNames) lookup(route *fiber.Route) string {
+	n.mutex.RLock()
+	defer n.mutex.RUnlock()
+
+	method := route.Method
+	if method == http.MethodHead {
+		method = http.MethodGet
+	}
+	if name, ok := n.names[method+" "+route.Path]; ok {
+		return name
+	}
+
+	return route.Path
+}
+
+// metricsHandler records the count and the latency of the requests, by the name of their route.
+func metricsHandler(m *metrics.Metrics, names *routeNames) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		// The method is backed by the request buffer, which is reused by the next request
+		method := utils.CopyString(c.Method())
+		err := c.Next()
+
+		// The error is only turned into a response by the error handler, later on
+		code := c.Response().StatusCode()
+		if err != nil {
+			code = http.StatusInternalServerError
+			var e *fiber.Error
+			if errors.As(err, &e) {
+				code = e.Code
+			}
+		}
+
+		m.ObserveRequest(names.lookup(c.Route()), method, code, time.Since(start))
+		return err
+	}
+}
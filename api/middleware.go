@@ -5,6 +5,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/recover"
 	"github.com/gofiber/fiber/v2/middleware/requestid"
+	"github.com/gringolito/dnsmasq-manager/api/actor"
 	"github.com/gringolito/dnsmasq-manager/api/middleware/fiberslog"
 	"github.com/gringolito/dnsmasq-manager/config"
 	"golang.org/x/exp/slog"
@@ -52,7 +53,7 @@ func (m middleware) Authentication(roles ...string) fiber.Handler {
 		return voidMiddleware
 	}
 
-	contextKey := "user"
+	contextKey := actor.ContextKey
 	if m.jwtConfig.ContextKey != "" {
 		contextKey = m.jwtConfig.ContextKey
 	}
@@ -37,6 +37,10 @@ func ConflictResponse(c *fiber.Ctx, message string, details string) error {
 	return ErrorResponse(c, http.StatusConflict, message, details)
 }
 
+func PreconditionFailedResponse(c *fiber.Ctx, message string, details string) error {
+	return ErrorResponse(c, http.StatusPreconditionFailed, message, details)
+}
+
 func BadRequestResponse(c *fiber.Ctx, message string, details string) error {
 	return ErrorResponse(c, http.StatusBadRequest, message, details)
 }
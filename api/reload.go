@@ -0,0 +1,45 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/api/presenter"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+)
+
+const (
+	ReloadFailedMessage = "The change was saved, but dnsmasq could not be reloaded."
+	ReloadFailedDetails = "dnsmasq may still be serving the previous configuration until it is reloaded. " +
+		"The reload failed with: %s."
+)
+
+// reloadHandler holds the response of the requests that changed the files until dnsmasq is
+// reloaded. The change is saved by then, so a failed reload is reported by a 202 Accepted response
+// telling why, rather than by an error status inviting to retry the change.
+func reloadHandler(scheduler *dnsmasq.Scheduler) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() == http.MethodGet || c.Method() == http.MethodHead {
+			return c.Next()
+		}
+
+		generation := scheduler.Generation()
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		changed := scheduler.Generation()
+		if changed == generation {
+			return nil
+		}
+
+		if err := scheduler.Wait(changed); err != nil {
+			// The entity tag of the saved change is kept
+			return presenter.ErrorResponse(c, http.StatusAccepted, ReloadFailedMessage,
+				fmt.Sprintf(ReloadFailedDetails, err.Error()))
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,70 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+)
+
+func TestReloadHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		reload     error
+		write      bool
+		wantStatus int
+		wantBody   string
+	}{
+		{name: "no change", write: false, wantStatus: http.StatusOK, wantBody: "saved"},
+		{name: "reloaded", write: true, wantStatus: http.StatusOK, wantBody: "saved"},
+		{name: "reload failed", reload: errors.New("boom"), write: true, wantStatus: http.StatusAccepted, wantBody: "boom"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := dnsmasq.NewScheduler(dnsmasq.ReloaderFunc(func() error { return tt.reload }), 0)
+			writer := scheduler.Writer(dnsmasq.WriterFunc(func(string, []byte, os.FileMode) error { return nil }))
+
+			app := fiber.New()
+			app.Use(reloadHandler(scheduler))
+			app.Post("/", func(c *fiber.Ctx) error {
+				if tt.write {
+					if err := writer.WriteFile("file.conf", nil, 0644); err != nil {
+						return err
+					}
+				}
+				c.Set(fiber.HeaderETag, `"tag"`)
+				return c.SendString("saved")
+			})
+
+			response, err := app.Test(httptest.NewRequest(http.MethodPost, "/", nil))
+			if err != nil {
+				t.Fatalf("Test() failed: %v", err)
+			}
+			body, _ := io.ReadAll(response.Body)
+
+			if response.StatusCode != tt.wantStatus {
+				t.Errorf("status = %d, want %d", response.StatusCode, tt.wantStatus)
+			}
+			if !strings.Contains(string(body), tt.wantBody) {
+				t.Errorf("body = %q, want it to contain %q", body, tt.wantBody)
+			}
+			if response.Header.Get(fiber.HeaderETag) != `"tag"` {
+				t.Errorf("ETag = %q, want it kept", response.Header.Get(fiber.HeaderETag))
+			}
+			if tt.reload != nil {
+				failure := struct{ Message string }{}
+				if err := json.Unmarshal(body, &failure); err != nil || failure.Message != ReloadFailedMessage {
+					t.Errorf("body = %q, want the reload failure", body)
+				}
+			}
+		})
+	}
+}
@@ -2,11 +2,22 @@ package api
 
 import (
 	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
 	"github.com/gofiber/fiber/v2/middleware/monitor"
 	"github.com/gringolito/dnsmasq-manager/api/handler"
 	"github.com/gringolito/dnsmasq-manager/api/middleware/fiberswagger"
 	"github.com/gringolito/dnsmasq-manager/api/scope"
+	"github.com/gringolito/dnsmasq-manager/pkg/audit"
+	"github.com/gringolito/dnsmasq-manager/pkg/backup"
+	"github.com/gringolito/dnsmasq-manager/pkg/cname"
+	"github.com/gringolito/dnsmasq-manager/pkg/dns"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/event"
+	"github.com/gringolito/dnsmasq-manager/pkg/git"
+	"github.com/gringolito/dnsmasq-manager/pkg/health"
 	"github.com/gringolito/dnsmasq-manager/pkg/host"
+	"github.com/gringolito/dnsmasq-manager/pkg/lease"
+	"github.com/gringolito/dnsmasq-manager/pkg/metrics"
 )
 
 type Router struct {
@@ -33,9 +44,49 @@ func NewRouter(root fiber.Router, mw Middleware) Router {
 	}
 }
 
+// Audit records the changes made by the API requests into the audit log. It must be set before the
+// APIs.
+func (r Router) Audit(log *audit.Log) {
+	r.api.Use(auditHandler(log))
+}
+
+func (r Router) AuditApi(log *audit.Log) {
+	r.apiv1.Get("/audit", r.mw.Authentication(scope.AuditCanRead...), handler.GetAuditLog(log)).Name("audit.get_all")
+}
+
+// Publish publishes the changes made by the API requests as events. It must be set before the APIs.
+func (r Router) Publish(publisher event.Publisher) {
+	r.api.Use(eventHandler(publisher))
+}
+
+func (r Router) EventApi(broker *event.Broker) {
+	r.apiv1.Get("/events", r.mw.Authentication(scope.EventCanRead...), handler.StreamEvents(broker)).Name("events.stream")
+}
+
+// Reload holds the responses of the API requests until the changes they made are reloaded by
+// dnsmasq. It must be set before the APIs.
+func (r Router) Reload(scheduler *dnsmasq.Scheduler) {
+	r.api.Use(reloadHandler(scheduler))
+}
+
+// Git commits the changes made by the API requests into the git repository. It must be set before
+// the APIs, on the app holding them.
+func (r Router) Git(app *fiber.App, repository *git.Repository) {
+	r.api.Use(gitHandler(repository, newRouteNames(app)))
+}
+
+func (r Router) GitApi(repository *git.Repository, targets []handler.RevertTarget) {
+	r.apiv1.Route("/history", func(router fiber.Router) {
+		router.Get("/commits", r.mw.Authentication(scope.HistoryCanRead...), handler.GetAllCommits(repository)).Name("get_all")
+		router.Get("/commits/:hash", r.mw.Authentication(scope.HistoryCanRead...), handler.GetCommit(repository)).Name("get")
+		router.Post("/commits/:hash/revert", r.mw.Authentication(scope.HistoryCanRevert...), handler.RevertCommit(repository, targets)).Name("revert")
+	}, "history.commits.")
+}
+
 func (r Router) HostApi(service host.Service) {
 	r.apiv1.Route("/static", func(router fiber.Router) {
 		router.Get("/hosts", r.mw.Authentication(scope.DhcpCanRead...), handler.GetAllStaticHosts(service)).Name("get_all")
+		router.Post("/hosts\\:batch", r.mw.Authentication(scope.DhcpCanChange...), handler.BatchStaticHosts(service)).Name("batch")
 		router.Get("/host", r.mw.Authentication(scope.DhcpCanRead...), handler.GetStaticHost(service)).Name("get")
 		router.Post("/host", r.mw.Authentication(scope.DhcpCanAdd...), handler.AddStaticHost(service)).Name("add")
 		router.Put("/host", r.mw.Authentication(scope.DhcpCanChange...), handler.UpdateStaticHost(service)).Name("update")
@@ -43,8 +94,56 @@ func (r Router) HostApi(service host.Service) {
 	}, "static.hosts.")
 }
 
-func (r Router) Metrics(cfg monitor.Config) {
-	r.root.Get("/metrics", monitor.New(cfg))
+func (r Router) HistoryApi(store *backup.Store, service host.Service) {
+	r.apiv1.Route("/static", func(router fiber.Router) {
+		router.Get("/history", r.mw.Authentication(scope.DhcpCanRead...), handler.GetStaticHistory(store)).Name("get_all")
+		router.Get("/history/:id", r.mw.Authentication(scope.DhcpCanRead...), handler.GetStaticSnapshot(store)).Name("get")
+		router.Post("/history/:id/restore", r.mw.Authentication(scope.DhcpCanRestore...), handler.RestoreStaticSnapshot(store, service)).Name("restore")
+	}, "static.history.")
+}
+
+func (r Router) LeaseApi(service lease.Service) {
+	r.apiv1.Route("/dhcp", func(router fiber.Router) {
+		router.Get("/leases", r.mw.Authentication(scope.DhcpCanRead...), handler.GetAllLeases(service)).Name("get_all")
+		router.Post("/leases/:mac/promote", r.mw.Authentication(scope.DhcpCanAdd...), handler.PromoteLease(service)).Name("promote")
+	}, "dhcp.leases.")
+}
+
+func (r Router) DnsApi(service dns.Service) {
+	r.apiv1.Route("/dns", func(router fiber.Router) {
+		router.Get("/records", r.mw.Authentication(scope.DnsCanRead...), handler.GetAllDnsRecords(service)).Name("get_all")
+		router.Post("/records", r.mw.Authentication(scope.DnsCanAdd...), handler.AddDnsRecord(service)).Name("add")
+		router.Get("/records/:type/:name", r.mw.Authentication(scope.DnsCanRead...), handler.GetDnsRecord(service)).Name("get")
+		router.Put("/records/:type/:name", r.mw.Authentication(scope.DnsCanChange...), handler.UpdateDnsRecord(service)).Name("update")
+		router.Delete("/records/:type/:name", r.mw.Authentication(scope.DnsCanChange...), handler.RemoveDnsRecord(service)).Name("remove")
+	}, "dns.records.")
+}
+
+func (r Router) CnameApi(service cname.Service) {
+	r.apiv1.Route("/dns", func(router fiber.Router) {
+		router.Get("/cnames", r.mw.Authentication(scope.DnsCanRead...), handler.GetAllCnames(service)).Name("get_all")
+		router.Post("/cnames", r.mw.Authentication(scope.DnsCanAdd...), handler.AddCname(service)).Name("add")
+		router.Get("/cnames/:alias", r.mw.Authentication(scope.DnsCanRead...), handler.GetCname(service)).Name("get")
+		router.Put("/cnames/:alias", r.mw.Authentication(scope.DnsCanChange...), handler.UpdateCname(service)).Name("update")
+		router.Delete("/cnames/:alias", r.mw.Authentication(scope.DnsCanChange...), handler.RemoveCname(service)).Name("remove")
+	}, "dns.cnames.")
+}
+
+// Metrics records the metrics of every request and serves all the metrics in the Prometheus format.
+// It must be set before any other route, on the app holding the routes.
+func (r Router) Metrics(app *fiber.App, m *metrics.Metrics) {
+	r.root.Use(metricsHandler(m, newRouteNames(app)))
+	r.root.Get("/metrics", adaptor.HTTPHandler(m.Handler())).Name("metrics")
+}
+
+// HealthApi serves the liveness and readiness probes, which are not authenticated.
+func (r Router) HealthApi(checker *health.Checker) {
+	r.root.Get("/healthz", handler.GetLiveness()).Name("health.live")
+	r.root.Get("/readyz", handler.GetReadiness(checker)).Name("health.ready")
+}
+
+func (r Router) Monitor(cfg monitor.Config) {
+	r.root.Get("/monitor", monitor.New(cfg)).Name("monitor")
 }
 
 func (r Router) SwaggerUI(openApiSpecFile string) {
@@ -0,0 +1,3 @@
+package scope
+
+var AuditCanRead = []string{DhcpAdmin}
@@ -9,3 +9,4 @@ const (
 var DhcpCanRead = []string{DhcpRead, DhcpWrite, DhcpAdmin}
 var DhcpCanAdd = []string{DhcpWrite, DhcpAdmin}
 var DhcpCanChange = []string{DhcpAdmin}
+var DhcpCanRestore = []string{DhcpAdmin}
@@ -0,0 +1,11 @@
+package scope
+
+const (
+	DnsRead  = "dns:read"
+	DnsWrite = "dns:write"
+	DnsAdmin = "dns:admin"
+)
+
+var DnsCanRead = []string{DnsRead, DnsWrite, DnsAdmin}
+var DnsCanAdd = []string{DnsWrite, DnsAdmin}
+var DnsCanChange = []string{DnsAdmin}
@@ -0,0 +1,5 @@
+package scope
+
+// The events of both the DHCP and the DNS resources are streamed, every user only receiving the
+// events of the resources it can read.
+var EventCanRead = []string{DhcpRead, DhcpWrite, DhcpAdmin, DnsRead, DnsWrite, DnsAdmin}
@@ -0,0 +1,6 @@
+package scope
+
+// The history holds the changes of both the DHCP and the DNS files, reverting a commit further
+// requires the admin scope of every file it changed.
+var HistoryCanRead = []string{DhcpRead, DhcpWrite, DhcpAdmin, DnsRead, DnsWrite, DnsAdmin}
+var HistoryCanRevert = []string{DhcpAdmin, DnsAdmin}
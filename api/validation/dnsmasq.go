@@ -0,0 +1,52 @@
+package validation
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+func init() {
+	validate.RegisterValidation("hwaddr", isHardwareAddress)
+	validate.RegisterValidation("ipv6addr", isIPv6Address)
+	validate.RegisterValidation("leasetime", isLeaseTime)
+	validate.RegisterValidation("dhcphostname", isDhcpHostName)
+	validate.RegisterValidation("dnsmasqtoken", isDnsmasqToken)
+	validate.RegisterValidation("dnsrecordtype", isDnsRecordType)
+}
+
+func isHardwareAddress(fl validator.FieldLevel) bool {
+	_, err := model.ParseHardwareAddress(fl.Field().String())
+	return err == nil
+}
+
+func isIPv6Address(fl validator.FieldLevel) bool {
+	_, err := model.ParseIPv6Address(fl.Field().String())
+	return err == nil
+}
+
+func isLeaseTime(fl validator.FieldLevel) bool {
+	return model.IsLeaseTime(fl.Field().String())
+}
+
+func isDhcpHostName(fl validator.FieldLevel) bool {
+	return model.IsHostName(fl.Field().String())
+}
+
+func isDnsRecordType(fl validator.FieldLevel) bool {
+	return model.IsDnsRecordType(fl.Field().String())
+}
+
+// A dnsmasq token must not be empty and cannot contain the ',' separator or blank characters.
+func isDnsmasqToken(fl validator.FieldLevel) bool {
+	token := fl.Field().String()
+	if token == "" {
+		return false
+	}
+
+	return strings.IndexFunc(token, func(r rune) bool {
+		return r == ',' || r == '#' || unicode.IsSpace(r) || !unicode.IsPrint(r)
+	}) < 0
+}
@@ -2,6 +2,7 @@ package validation
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/go-playground/validator/v10"
 )
@@ -14,7 +15,7 @@ type error struct {
 
 func newError(err validator.FieldError) error {
 	var reason string
-	if err.Tag() == "required" {
+	if strings.HasPrefix(err.Tag(), "required") {
 		reason = fmt.Sprintf("The %s field is required.", err.Field())
 	} else {
 		reason = fmt.Sprintf("The %s field must be of type %s.", err.Field(), err.Tag())
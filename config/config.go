@@ -1,7 +1,9 @@
 package config
 
 import (
+	"os"
 	"strings"
+	"time"
 
 	"github.com/spf13/viper"
 )
@@ -34,10 +36,34 @@ const (
 	LogFormatPlainText = "text"
 )
 
+// Reload.Method constants
+const (
+	NoReload      = "none"
+	ReloadSignal  = "signal"
+	ReloadCommand = "command"
+)
+
 // Other default constants
 const (
-	DefaultDhcpStaticHostFile = "/etc/dnsmasq.d/04-dhcp-static-leases.conf"
-	DefaultServerHttpPort     = 6904
+	DefaultDhcpStaticHostFile     = "/etc/dnsmasq.d/04-dhcp-static-leases.conf"
+	DefaultDhcpStaticHostFileMode = os.FileMode(0644)
+	DefaultDhcpStaticBackupDir    = "/var/lib/dnsmasq-manager/backups"
+	DefaultDhcpStaticBackupCount  = 10
+	DefaultDhcpLeasesFile         = "/var/lib/misc/dnsmasq.leases"
+	DefaultDnsRecordsFile         = "/etc/dnsmasq.d/05-dns-records.conf"
+	DefaultDnsRecordsFileMode     = os.FileMode(0644)
+	DefaultDnsCnameFile           = "/etc/dnsmasq.d/06-dns-cnames.conf"
+	DefaultDnsCnameFileMode       = os.FileMode(0644)
+	DefaultAuditFile              = "/var/log/dnsmasq-manager/audit.log"
+	DefaultAuditFileMode          = os.FileMode(0600)
+	DefaultEventsBuffer           = 1000
+	DefaultWebhookQueueDir        = "/var/lib/dnsmasq-manager/webhooks"
+	DefaultWebhookRetryAttempts   = 10
+	DefaultWebhookRetryDelay      = 5 * time.Second
+	DefaultWebhookRetryMaxDelay   = 10 * time.Minute
+	DefaultReloadPidFile          = "/run/dnsmasq/dnsmasq.pid"
+	DefaultReloadDelay            = 500 * time.Millisecond
+	DefaultServerHttpPort         = 6904
 )
 
 type Config struct {
@@ -47,9 +73,66 @@ type Config struct {
 	}
 	Host struct {
 		Static struct {
+			File   string
+			Mode   os.FileMode
+			Backup struct {
+				Dir   string
+				Count int
+			}
+		}
+	}
+	Lease struct {
+		File string
+	}
+	Dns struct {
+		Records struct {
+			File string
+			Mode os.FileMode
+		}
+		Cname struct {
 			File string
+			Mode os.FileMode
+		}
+	}
+	Git struct {
+		Enabled bool
+		Dir     string
+	}
+	Audit struct {
+		File string
+		Mode os.FileMode
+	}
+	Events struct {
+		Buffer int
+	}
+	Webhooks struct {
+		Queue string
+		Retry struct {
+			Attempts int
+			Delay    time.Duration
+			MaxDelay time.Duration
+		}
+		Subscriptions []struct {
+			URL    string
+			Secret string
+			Events []string
 		}
 	}
+	Metrics struct {
+		Subnets []string
+	}
+	Health struct {
+		Dnsmasq bool
+	}
+	Validation struct {
+		Command string
+	}
+	Reload struct {
+		Method  string
+		PidFile string
+		Command string
+		Delay   time.Duration
+	}
 	Server struct {
 		Port int
 	}
@@ -65,6 +148,24 @@ func newDefaultConfig() *Config {
 	def := Config{}
 	def.Auth.Method = NoAuth
 	def.Host.Static.File = DefaultDhcpStaticHostFile
+	def.Host.Static.Mode = DefaultDhcpStaticHostFileMode
+	def.Host.Static.Backup.Dir = DefaultDhcpStaticBackupDir
+	def.Host.Static.Backup.Count = DefaultDhcpStaticBackupCount
+	def.Lease.File = DefaultDhcpLeasesFile
+	def.Dns.Records.File = DefaultDnsRecordsFile
+	def.Dns.Records.Mode = DefaultDnsRecordsFileMode
+	def.Dns.Cname.File = DefaultDnsCnameFile
+	def.Dns.Cname.Mode = DefaultDnsCnameFileMode
+	def.Audit.File = DefaultAuditFile
+	def.Audit.Mode = DefaultAuditFileMode
+	def.Events.Buffer = DefaultEventsBuffer
+	def.Webhooks.Queue = DefaultWebhookQueueDir
+	def.Webhooks.Retry.Attempts = DefaultWebhookRetryAttempts
+	def.Webhooks.Retry.Delay = DefaultWebhookRetryDelay
+	def.Webhooks.Retry.MaxDelay = DefaultWebhookRetryMaxDelay
+	def.Reload.Method = NoReload
+	def.Reload.PidFile = DefaultReloadPidFile
+	def.Reload.Delay = DefaultReloadDelay
 	def.Server.Port = DefaultServerHttpPort
 	def.Log.Level = LogLevelInfo
 	def.Log.Format = LogFormatJSON
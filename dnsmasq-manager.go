@@ -1,16 +1,32 @@
 package main
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"log"
+	"net"
 	"os"
+	"path/filepath"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/gofiber/fiber/v2/middleware/monitor"
 	"github.com/gringolito/dnsmasq-manager/api"
+	"github.com/gringolito/dnsmasq-manager/api/handler"
+	"github.com/gringolito/dnsmasq-manager/api/scope"
 	"github.com/gringolito/dnsmasq-manager/config"
+	"github.com/gringolito/dnsmasq-manager/pkg/audit"
+	"github.com/gringolito/dnsmasq-manager/pkg/backup"
+	"github.com/gringolito/dnsmasq-manager/pkg/cname"
+	"github.com/gringolito/dnsmasq-manager/pkg/dns"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/event"
+	"github.com/gringolito/dnsmasq-manager/pkg/git"
+	"github.com/gringolito/dnsmasq-manager/pkg/health"
 	"github.com/gringolito/dnsmasq-manager/pkg/host"
+	"github.com/gringolito/dnsmasq-manager/pkg/lease"
+	"github.com/gringolito/dnsmasq-manager/pkg/metrics"
+	"github.com/gringolito/dnsmasq-manager/pkg/webhook"
 	"golang.org/x/exp/slog"
 )
 
@@ -71,10 +87,244 @@ func setupLogger(cfg *config.Config) *slog.Logger {
 	return logger
 }
 
-func addHostApi(router api.Router, cfg *config.Config) {
-	hostRepository := host.NewRepository(cfg.Host.Static.File)
+// setupWriters returns the function wrapping the writer of each dnsmasq file, which validates the
+// files before they are written, and reloads dnsmasq after the changes, when configured to. The
+// changes are also recorded by the watcher, so they are not reported as made by another tool, and
+// counted by the metrics, along with the reloads.
+func setupWriters(router api.Router, cfg *config.Config, watcher *dnsmasq.Watcher, m *metrics.Metrics) (func(dnsmasq.Writer) dnsmasq.Writer, error) {
+	var reloader dnsmasq.Reloader
+	switch cfg.Reload.Method {
+	case config.NoReload:
+	case config.ReloadSignal:
+		slog.Warn("dnsmasq does not reread its configuration files on SIGHUP, the changes of the files " +
+			"not loaded through dhcp-hostsfile or addn-hosts only take effect on its next restart")
+		reloader = dnsmasq.NewSignalReloader(cfg.Reload.PidFile)
+	case config.ReloadCommand:
+		if cfg.Reload.Command == "" {
+			return nil, errors.New("missing reload command")
+		}
+		reloader = dnsmasq.NewCommandReloader(cfg.Reload.Command)
+	default:
+		return nil, fmt.Errorf("invalid reload method: %s", cfg.Reload.Method)
+	}
+
+	var scheduler *dnsmasq.Scheduler
+	if reloader != nil {
+		scheduler = dnsmasq.NewScheduler(m.Reloader(reloader), cfg.Reload.Delay)
+		router.Reload(scheduler)
+	}
+
+	return func(writer dnsmasq.Writer) dnsmasq.Writer {
+		writer = watcher.Writer(writer)
+		if cfg.Validation.Command != "" {
+			writer = dnsmasq.NewValidator(cfg.Validation.Command, writer)
+		}
+		if scheduler != nil {
+			writer = scheduler.Writer(writer)
+		}
+		return m.Writer(writer)
+	}, nil
+}
+
+// setupMetrics counts the static hosts, by the configured subnets, and the leases.
+func setupMetrics(m *metrics.Metrics, cfg *config.Config, hostService host.Service, leaseService lease.Service) error {
+	subnets := make([]*net.IPNet, 0, len(cfg.Metrics.Subnets))
+	for _, s := range cfg.Metrics.Subnets {
+		_, subnet, err := net.ParseCIDR(s)
+		if err != nil {
+			return fmt.Errorf("invalid metrics subnet: %s", s)
+		}
+		subnets = append(subnets, subnet)
+	}
+
+	return m.Register(
+		metrics.NewHostCollector(hostService, subnets),
+		metrics.NewLeaseCollector(leaseService),
+	)
+}
+
+// setupHealth checks that the static hosts file can be read, written and parsed, that the signing
+// key of the authentication can be loaded and, when configured to, that dnsmasq is running.
+func setupHealth(router api.Router, cfg *config.Config) {
+	checker := health.NewChecker()
+	checker.Add("static-hosts-file", health.FileAccess(cfg.Host.Static.File))
+	// The file itself is parsed, as the cached hosts may lag behind it
+	checker.Add("static-hosts-parse", func() error {
+		return host.CheckFile(cfg.Host.Static.File)
+	})
+	if cfg.Auth.Method != config.NoAuth {
+		checker.Add("jwt-key", func() error {
+			return api.CheckSigningKey(cfg)
+		})
+	}
+	if cfg.Health.Dnsmasq {
+		checker.Add("dnsmasq", health.ProcessAlive(cfg.Reload.PidFile))
+	}
+
+	router.HealthApi(checker)
+}
+
+func setupAudit(router api.Router, cfg *config.Config) {
+	if cfg.Audit.File == "" {
+		return
+	}
+
+	log := audit.NewLog(cfg.Audit.File, cfg.Audit.Mode)
+	router.Audit(log)
+	router.AuditApi(log)
+}
+
+func setupWebhooks(cfg *config.Config) (*webhook.Dispatcher, error) {
+	if len(cfg.Webhooks.Subscriptions) == 0 {
+		return nil, nil
+	}
+
+	subscriptions := make([]webhook.Subscription, 0, len(cfg.Webhooks.Subscriptions))
+	for _, s := range cfg.Webhooks.Subscriptions {
+		subscriptions = append(subscriptions, webhook.Subscription{URL: s.URL, Secret: s.Secret, Events: s.Events})
+	}
+
+	dispatcher, err := webhook.NewDispatcher(cfg.Webhooks.Queue, subscriptions, webhook.Retry{
+		Attempts: cfg.Webhooks.Retry.Attempts,
+		Delay:    cfg.Webhooks.Retry.Delay,
+		MaxDelay: cfg.Webhooks.Retry.MaxDelay,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return dispatcher, nil
+}
+
+// setupEvents publishes the changes made through the API to the webhooks and to the event stream,
+// which also receives the changes of the leases and the files modified by other tools. The latter
+// are also committed into the git repository, when enabled.
+func setupEvents(router api.Router, cfg *config.Config, repository *git.Repository) (*event.Broker, *dnsmasq.Watcher, error) {
+	broker := event.NewBroker(cfg.Events.Buffer)
+	router.EventApi(broker)
+
+	dispatcher, err := setupWebhooks(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	if dispatcher != nil {
+		router.Publish(event.Publishers{broker, dispatcher})
+	} else {
+		router.Publish(broker)
+	}
+
+	scopes := map[string][]string{
+		filepath.Clean(cfg.Host.Static.File): scope.DhcpCanRead,
+		filepath.Clean(cfg.Dns.Records.File): scope.DnsCanRead,
+		filepath.Clean(cfg.Dns.Cname.File):   scope.DnsCanRead,
+	}
+	modified := api.PublishFileModified(broker, scopes)
+	if repository != nil {
+		modified = api.CommitFileModified(repository, modified)
+	}
+	watcher, err := dnsmasq.NewWatcher(modified, cfg.Host.Static.File, cfg.Dns.Records.File, cfg.Dns.Cname.File)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return broker, watcher, nil
+}
+
+// setupGit opens the git repository holding the managed files, when enabled, committing any change
+// made while the service was not running.
+func setupGit(cfg *config.Config) (*git.Repository, error) {
+	if !cfg.Git.Enabled {
+		return nil, nil
+	}
+
+	// The repository is not placed next to the files by default, as they usually live in the
+	// configuration directory of dnsmasq
+	if cfg.Git.Dir == "" {
+		return nil, errors.New("missing git directory")
+	}
+
+	repository, err := git.Open(cfg.Git.Dir, cfg.Host.Static.File, cfg.Dns.Records.File, cfg.Dns.Cname.File)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := repository.Commit(git.Committer, api.ExternalChangesMessage); err != nil {
+		return nil, err
+	}
+
+	return repository, nil
+}
+
+func addHostApi(router api.Router, cfg *config.Config, writers func(dnsmasq.Writer) dnsmasq.Writer, inUse dnsmasq.NamesInUse) (host.Service, error) {
+	writer := dnsmasq.FileWriter
+	var store *backup.Store
+	if cfg.Host.Static.Backup.Count > 0 {
+		// The snapshot is only taken once the change is validated
+		store = backup.NewStore(cfg.Host.Static.File, cfg.Host.Static.Backup.Dir, cfg.Host.Static.Backup.Count)
+		writer = store.Writer(writer)
+	}
+
+	hostRepository, err := host.NewCachedRepository(
+		host.NewRepository(cfg.Host.Static.File, cfg.Host.Static.Mode, writers(writer), inUse),
+		cfg.Host.Static.File,
+	)
+	if err != nil {
+		return nil, err
+	}
+
 	hostService := host.NewService(hostRepository)
 	router.HostApi(hostService)
+	if store != nil {
+		router.HistoryApi(store, hostService)
+	}
+
+	return hostService, nil
+}
+
+func addLeaseApi(router api.Router, cfg *config.Config, hostService host.Service, publisher event.Publisher) (lease.Service, error) {
+	leaseRepository := lease.NewRepository(cfg.Lease.File)
+	if err := lease.Watch(cfg.Lease.File, leaseRepository, api.PublishLeaseChanges(publisher)); err != nil {
+		return nil, err
+	}
+
+	leaseService := lease.NewService(leaseRepository, hostService)
+	router.LeaseApi(leaseService)
+
+	return leaseService, nil
+}
+
+func addDnsApi(router api.Router, cfg *config.Config, writers func(dnsmasq.Writer) dnsmasq.Writer, inUse dnsmasq.NamesInUse) dns.Service {
+	dnsRepository := dns.NewRepository(cfg.Dns.Records.File, cfg.Dns.Records.Mode, writers(dnsmasq.FileWriter), inUse)
+	dnsService := dns.NewService(dnsRepository)
+	router.DnsApi(dnsService)
+
+	return dnsService
+}
+
+func addCnameApi(router api.Router, cnameRepository cname.Repository, hostService host.Service, dnsService dns.Service) cname.Service {
+	cnameService := cname.NewService(cnameRepository, hostService, dnsService)
+	router.CnameApi(cnameService)
+
+	return cnameService
+}
+
+func addGitApi(router api.Router, cfg *config.Config, repository *git.Repository, hostService host.Service, dnsService dns.Service, cnameService cname.Service) error {
+	targets := []handler.RevertTarget{
+		{Path: cfg.Host.Static.File, Scopes: scope.DhcpCanChange, Restore: hostService.Restore, HostChanges: host.FileChanges},
+		{Path: cfg.Dns.Records.File, Scopes: scope.DnsCanChange, Restore: dnsService.Restore},
+		{Path: cfg.Dns.Cname.File, Scopes: scope.DnsCanChange, Restore: cnameService.Restore},
+	}
+	for i := range targets {
+		path, err := filepath.Abs(targets[i].Path)
+		if err != nil {
+			return err
+		}
+		targets[i].Path = path
+	}
+
+	router.GitApi(repository, targets)
+
+	return nil
 }
 
 func main() {
@@ -99,12 +349,57 @@ func main() {
 		os.Exit(1)
 	}
 
+	m := metrics.New()
 	router := api.NewRouter(app, middleware)
+	router.Metrics(app, m)
 	router.SwaggerUI(OpenApiSpecFile)
-	router.Metrics(monitor.Config{
+	router.Monitor(monitor.Config{
 		Title: fmt.Sprintf("%s Monitor", AppName),
 	})
-	addHostApi(router, cfg)
+	setupAudit(router, cfg)
+	repository, err := setupGit(cfg)
+	if err != nil {
+		logger.Error(err.Error(), slog.String("dir", cfg.Git.Dir))
+		os.Exit(1)
+	}
+	broker, watcher, err := setupEvents(router, cfg, repository)
+	if err != nil {
+		logger.Error(err.Error(), slog.String("queue", cfg.Webhooks.Queue))
+		os.Exit(1)
+	}
+	writers, err := setupWriters(router, cfg, watcher, m)
+	if err != nil {
+		logger.Error(err.Error(), slog.String("method", cfg.Reload.Method))
+		os.Exit(1)
+	}
+	if repository != nil {
+		router.Git(app, repository)
+	}
+	// The CNAME aliases are checked by the host and DNS changes, which must not leave them dangling
+	cnameRepository := cname.NewRepository(cfg.Dns.Cname.File, cfg.Dns.Cname.Mode, writers(dnsmasq.FileWriter))
+	hostService, err := addHostApi(router, cfg, writers, cname.InUse(cnameRepository))
+	if err != nil {
+		logger.Error(err.Error(), slog.String("file", cfg.Host.Static.File))
+		os.Exit(1)
+	}
+	setupHealth(router, cfg)
+	leaseService, err := addLeaseApi(router, cfg, hostService, broker)
+	if err != nil {
+		logger.Error(err.Error(), slog.String("file", cfg.Lease.File))
+		os.Exit(1)
+	}
+	if err := setupMetrics(m, cfg, hostService, leaseService); err != nil {
+		logger.Error(err.Error())
+		os.Exit(1)
+	}
+	dnsService := addDnsApi(router, cfg, writers, cname.InUse(cnameRepository))
+	cnameService := addCnameApi(router, cnameRepository, hostService, dnsService)
+	if repository != nil {
+		if err := addGitApi(router, cfg, repository, hostService, dnsService, cnameService); err != nil {
+			logger.Error(err.Error(), slog.String("dir", cfg.Git.Dir))
+			os.Exit(1)
+		}
+	}
 
 	if err := app.Listen(fmt.Sprintf(":%d", cfg.Server.Port)); err != nil {
 		logger.Error(err.Error(), slog.Int("listeningPort", cfg.Server.Port))
@@ -0,0 +1,145 @@
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// Entry records a request made to change the managed files.
+type Entry struct {
+	Time time.Time
+	// Subject and Name identify the user, from the claims of the JWT
+	Subject string `json:",omitempty"`
+	Name    string `json:",omitempty"`
+	// Scope is the scope that granted the access to the user
+	Scope     string `json:",omitempty"`
+	RequestID string
+	SourceIP  string
+	// Operation is the method and the URL of the request
+	Operation string
+	Status    int
+	// Before and After are the values changed by the request, if any
+	Before json.RawMessage `json:",omitempty"`
+	After  json.RawMessage `json:",omitempty"`
+}
+
+// Query selects audit entries. The zero value selects all the entries.
+type Query struct {
+	// Subject selects the entries of a single user
+	Subject string
+	// Since and Until select the entries within the time range, when set
+	Since time.Time
+	Until time.Time
+	// Limit is the maximum number of entries, or 0 for all of them
+	Limit int
+}
+
+// maxEntrySize bounds the size of a single entry when reading the log
+const maxEntrySize = 16 * 1024 * 1024
+
+// Log appends the entries to a JSON-lines file, which is never rewritten.
+type Log struct {
+	path  string
+	mode  os.FileMode
+	mutex sync.Mutex
+}
+
+// NewLog creates the log of the file, which is created along with its directory on the first entry.
+func NewLog(path string, mode os.FileMode) *Log {
+	return &Log{
+		path: path,
+		mode: mode,
+	}
+}
+
+func (l *Log) Append(entry *Entry) error {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	if err := os.MkdirAll(filepath.Dir(l.path), 0700); err != nil {
+		return err
+	}
+
+	file, err := os.OpenFile(l.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, l.mode)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	// A single write per entry, so concurrent writers never interleave their entries
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return err
+	}
+
+	return file.Sync()
+}
+
+// Find returns the entries matching the query, the most recent first.
+func (l *Log) Find(q Query) ([]Entry, error) {
+	file, err := os.Open(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := []Entry{}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, maxEntrySize)
+	for scanner.Scan() {
+		entry := Entry{}
+		if err := json.Unmarshal(scanner.Bytes(), &entry); err != nil {
+			slog.Warn("Skipping invalid audit entry",
+				slog.String("file", l.path),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+
+		if q.match(&entry) {
+			entries = append(entries, entry)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Most recent first
+	for i, j := 0, len(entries)-1; i < j; i, j = i+1, j-1 {
+		entries[i], entries[j] = entries[j], entries[i]
+	}
+	if q.Limit > 0 && len(entries) > q.Limit {
+		entries = entries[:q.Limit]
+	}
+
+	return entries, nil
+}
+
+func (q Query) match(entry *Entry) bool {
+	if q.Subject != "" && entry.Subject != q.Subject {
+		return false
+	}
+	if !q.Since.IsZero() && entry.Time.Before(q.Since) {
+		return false
+	}
+	if !q.Until.IsZero() && entry.Time.After(q.Until) {
+		return false
+	}
+
+	return true
+}
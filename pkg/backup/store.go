@@ -0,0 +1,204 @@
+package backup
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+// Snapshot is a copy of the file, taken right before it was replaced.
+type Snapshot struct {
+	// ID is the time the snapshot was taken, in a sortable format
+	ID   string
+	Time time.Time
+	Size int64
+}
+
+// idLayout formats the snapshot IDs, which sort as the snapshots were taken
+const idLayout = "20060102T150405.000000000Z"
+
+const snapshotFileMode = os.FileMode(0600)
+
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// Store keeps the last snapshots of a file in a directory, named after the file and their IDs.
+type Store struct {
+	path  string
+	dir   string
+	count int
+	mutex sync.Mutex
+}
+
+// NewStore creates the store of the snapshots of the file at path, keeping up to count snapshots in
+// dir.
+func NewStore(path string, dir string, count int) *Store {
+	return &Store{
+		path:  path,
+		dir:   dir,
+		count: count,
+	}
+}
+
+// Path returns the path of the file the snapshots are taken from.
+func (s *Store) Path() string {
+	return s.path
+}
+
+// Writer chains a writer that takes a snapshot of the file before it is replaced.
+func (s *Store) Writer(next dnsmasq.Writer) dnsmasq.Writer {
+	return dnsmasq.WriterFunc(func(path string, data []byte, mode os.FileMode) error {
+		if path == s.path {
+			if err := s.take(); err != nil {
+				slog.Error("Error taking a snapshot of the file",
+					slog.String("file", s.path),
+					slog.String("error", err.Error()),
+				)
+				return err
+			}
+		}
+
+		return next.WriteFile(path, data, mode)
+	})
+}
+
+// List returns the snapshots, the most recent first.
+func (s *Store) List() ([]Snapshot, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.list()
+}
+
+// Read returns the content of the snapshot.
+func (s *Store) Read(id string) (*Snapshot, []byte, error) {
+	snapshot, err := s.find(id)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	data, err := os.ReadFile(s.snapshotPath(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil, ErrSnapshotNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return snapshot, data, nil
+}
+
+func (s *Store) find(id string) (*Snapshot, error) {
+	if _, err := time.Parse(idLayout, id); err != nil {
+		return nil, ErrSnapshotNotFound
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	snapshots, err := s.list()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, snapshot := range snapshots {
+		if snapshot.ID == id {
+			return &snapshot, nil
+		}
+	}
+
+	return nil, ErrSnapshotNotFound
+}
+
+func (s *Store) take() error {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		// Nothing to be kept yet
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0700); err != nil {
+		return err
+	}
+
+	id := time.Now().UTC().Format(idLayout)
+	if err := dnsmasq.WriteFile(s.snapshotPath(id), data, snapshotFileMode); err != nil {
+		return err
+	}
+
+	return s.prune()
+}
+
+// prune removes the oldest snapshots beyond the count to be kept.
+func (s *Store) prune() error {
+	snapshots, err := s.list()
+	if err != nil {
+		return err
+	}
+
+	for i := s.count; i < len(snapshots); i++ {
+		if err := os.Remove(s.snapshotPath(snapshots[i].ID)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (s *Store) list() ([]Snapshot, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Snapshot{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := filepath.Base(s.path) + "."
+	snapshots := []Snapshot{}
+	for _, entry := range entries {
+		id, found := strings.CutPrefix(entry.Name(), prefix)
+		if !found || !entry.Type().IsRegular() {
+			continue
+		}
+
+		taken, err := time.Parse(idLayout, id)
+		if err != nil {
+			continue
+		}
+
+		info, err := entry.Info()
+		if err != nil {
+			return nil, err
+		}
+
+		snapshots = append(snapshots, Snapshot{
+			ID:   id,
+			Time: taken,
+			Size: info.Size(),
+		})
+	}
+
+	slices.SortFunc(snapshots, func(a, b Snapshot) bool {
+		return a.ID > b.ID
+	})
+
+	return snapshots, nil
+}
+
+func (s *Store) snapshotPath(id string) string {
+	return filepath.Join(s.dir, filepath.Base(s.path)+"."+id)
+}
@@ -0,0 +1,235 @@
+package cname
+
+import (
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"golang.org/x/exp/slog"
+)
+
+type Repository interface {
+	Delete(cname *model.Cname) (*model.Cname, error)
+	DeleteByAlias(alias string) (*model.Cname, error)
+	Find(cname *model.Cname) (*model.Cname, error)
+	FindAll() (*[]model.Cname, error)
+	FindByAlias(alias string) (*model.Cname, error)
+	Save(cname *model.Cname) error
+	Update(current *model.Cname, cname *model.Cname) error
+	// Restore replaces the whole CNAME aliases file with data, which must only hold valid entries.
+	Restore(data []byte) error
+	// Transaction runs fn as a single critical section over the CNAME aliases. All the changes made
+	// through the given Repository are written at once when fn succeeds, and discarded otherwise.
+	Transaction(fn func(Repository) error) error
+}
+
+var ErrCnameNotFound = errors.New("CNAME alias not found")
+
+const cnameDirective = "cname"
+
+type repository struct {
+	store *dnsmasq.Store[model.Cname]
+}
+
+// NewRepository creates the repository of the file, writing the changes through the writer.
+func NewRepository(cnameFilePath string, fileMode os.FileMode, writer dnsmasq.Writer) Repository {
+	return &repository{
+		store: dnsmasq.NewStore("CNAME aliases", cnameFilePath, fileMode, writer, format),
+	}
+}
+
+var format = dnsmasq.Format[model.Cname]{
+	Parse:  parseLine,
+	Config: (*model.Cname).ToConfig,
+}
+
+func (r *repository) FindAll() (*[]model.Cname, error) {
+	doc, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.FindAll()
+}
+
+func (r *repository) Find(cname *model.Cname) (*model.Cname, error) {
+	doc, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.Find(cname)
+}
+
+func (r *repository) FindByAlias(alias string) (*model.Cname, error) {
+	doc, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.FindByAlias(alias)
+}
+
+func (r *repository) Save(cname *model.Cname) error {
+	return r.Transaction(func(tx Repository) error {
+		return tx.Save(cname)
+	})
+}
+
+func (r *repository) Update(current *model.Cname, cname *model.Cname) error {
+	return r.Transaction(func(tx Repository) error {
+		return tx.Update(current, cname)
+	})
+}
+
+func (r *repository) Delete(cname *model.Cname) (deleted *model.Cname, err error) {
+	err = r.Transaction(func(tx Repository) error {
+		deleted, err = tx.Delete(cname)
+		return err
+	})
+	return deleted, err
+}
+
+func (r *repository) DeleteByAlias(alias string) (deleted *model.Cname, err error) {
+	err = r.Transaction(func(tx Repository) error {
+		deleted, err = tx.DeleteByAlias(alias)
+		return err
+	})
+	return deleted, err
+}
+
+func (r *repository) Restore(data []byte) error {
+	return r.Transaction(func(tx Repository) error {
+		return tx.Restore(data)
+	})
+}
+
+func (r *repository) Transaction(fn func(Repository) error) error {
+	return r.store.Transaction(func(doc *dnsmasq.Document[model.Cname]) error {
+		before := aliases(doc.Entries())
+		if err := fn(&document{doc: doc}); err != nil {
+			return err
+		}
+
+		if !doc.Changed() {
+			return nil
+		}
+
+		// The aliases may be targeted by the other CNAMEs as well, which must not be left dangling
+		removed := dnsmasq.RemovedNames(before, aliases(doc.Entries()))
+		if len(removed) == 0 {
+			return nil
+		}
+
+		return InUse(&document{doc: doc})(removed)
+	})
+}
+
+func aliases(cnames []model.Cname) []string {
+	names := []string{}
+	for _, cname := range cnames {
+		names = append(names, cname.Aliases...)
+	}
+
+	return names
+}
+
+func (r *repository) load() (*document, error) {
+	doc, err := r.store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return &document{doc: doc}, nil
+}
+
+func parseLine(line string) (*model.Cname, error) {
+	if name, _, ok := dnsmasq.Directive(line); !ok || name != cnameDirective {
+		slog.Debug("Skipping line", slog.String("line", line))
+		return nil, nil
+	}
+	slog.Debug("Parsing line", slog.String("line", line))
+
+	cname := model.Cname{}
+	err := cname.FromConfig(line)
+	if err != nil {
+		slog.Error("Failed to parse CNAME entry",
+			slog.String("entry", line),
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return &cname, nil
+}
+
+// document implements the Repository operations in memory over the parsed CNAME aliases file, to
+// be used within a transaction.
+type document struct {
+	doc *dnsmasq.Document[model.Cname]
+}
+
+func (d *document) FindAll() (*[]model.Cname, error) {
+	cnames := d.doc.Entries()
+	return &cnames, nil
+}
+
+func (d *document) Find(cname *model.Cname) (*model.Cname, error) {
+	return d.doc.Find(sameCname(cname)), nil
+}
+
+func (d *document) FindByAlias(alias string) (*model.Cname, error) {
+	return d.doc.Find(sameAlias(alias)), nil
+}
+
+func (d *document) Save(cname *model.Cname) error {
+	d.doc.Insert(cname)
+	return nil
+}
+
+func (d *document) Update(current *model.Cname, cname *model.Cname) error {
+	if !d.doc.Update(sameCname(current), cname) {
+		return ErrCnameNotFound
+	}
+
+	return nil
+}
+
+func (d *document) Delete(cname *model.Cname) (*model.Cname, error) {
+	return d.doc.Delete(sameCname(cname)), nil
+}
+
+func (d *document) DeleteByAlias(alias string) (*model.Cname, error) {
+	return d.doc.Delete(sameAlias(alias)), nil
+}
+
+func (d *document) Restore(data []byte) error {
+	return d.doc.Restore(data)
+}
+
+// Transaction runs fn straight away, the document is already part of one.
+func (d *document) Transaction(fn func(Repository) error) error {
+	return fn(d)
+}
+
+type Filter func(model.Cname) bool
+
+func sameCname(cname *model.Cname) Filter {
+	return func(other model.Cname) bool {
+		return cname.Equal(other)
+	}
+}
+
+func sameAlias(alias string) Filter {
+	return func(other model.Cname) bool {
+		return alias != "" && other.HasAlias(alias)
+	}
+}
+
+func sameTarget(target string) Filter {
+	return func(other model.Cname) bool {
+		return strings.EqualFold(target, other.Target)
+	}
+}
@@ -0,0 +1,82 @@
+package cname
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+func TestRepositoryTargetedAliases(t *testing.T) {
+	const file = "cname=files,nas\ncname=f2,files\n"
+
+	tests := []struct {
+		name      string
+		fn        func(Repository) error
+		wantInUse bool
+	}{
+		{
+			name: "remove a targeted alias",
+			fn: func(r Repository) error {
+				_, err := r.DeleteByAlias("FILES")
+				return err
+			},
+			wantInUse: true,
+		},
+		{
+			name: "rename a targeted alias",
+			fn: func(r Repository) error {
+				return r.Update(&model.Cname{Aliases: []string{"files"}, Target: "nas"}, &model.Cname{Aliases: []string{"docs"}, Target: "nas"})
+			},
+			wantInUse: true,
+		},
+		{
+			name: "retarget a targeted alias",
+			fn: func(r Repository) error {
+				return r.Update(&model.Cname{Aliases: []string{"files"}, Target: "nas"}, &model.Cname{Aliases: []string{"files"}, Target: "web"})
+			},
+		},
+		{
+			name: "remove the last alias of the chain",
+			fn: func(r Repository) error {
+				_, err := r.DeleteByAlias("f2")
+				return err
+			},
+		},
+		{
+			name: "remove the whole chain",
+			fn: func(r Repository) error {
+				return r.Transaction(func(tx Repository) error {
+					if _, err := tx.DeleteByAlias("f2"); err != nil {
+						return err
+					}
+					_, err := tx.DeleteByAlias("files")
+					return err
+				})
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "cnames.conf")
+			if err := os.WriteFile(path, []byte(file), 0644); err != nil {
+				t.Fatal(err)
+			}
+			repository := NewRepository(path, 0644, dnsmasq.FileWriter)
+
+			err := tt.fn(repository)
+			var inUse dnsmasq.NameInUseError
+			if errors.As(err, &inUse) != tt.wantInUse || (!tt.wantInUse && err != nil) {
+				t.Fatalf("got %v, want in use %v", err, tt.wantInUse)
+			}
+
+			if data, _ := os.ReadFile(path); tt.wantInUse && string(data) != file {
+				t.Errorf("file = %q, want it unchanged", data)
+			}
+		})
+	}
+}
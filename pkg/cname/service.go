@@ -0,0 +1,192 @@
+package cname
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dns"
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/host"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"golang.org/x/exp/slices"
+)
+
+type Service interface {
+	Insert(cname *model.Cname) error
+	// UpdateByAlias replaces the CNAME holding the alias, or adds the CNAME when there is none. It
+	// returns the replaced CNAME, if any.
+	UpdateByAlias(alias string, cname *model.Cname) (*model.Cname, error)
+	FetchAll() (*[]model.Cname, error)
+	FetchByAlias(alias string) (*model.Cname, error)
+	RemoveByAlias(alias string) (*model.Cname, error)
+	// Restore replaces all the CNAME aliases with the ones of a previous version of the CNAME aliases file.
+	Restore(data []byte) error
+}
+
+type service struct {
+	repository Repository
+	hosts      host.Service
+	records    dns.Service
+}
+
+// NewService creates the CNAME service. The static hosts and the DNS records are the names that
+// can be targeted by an alias, either directly or through other aliases.
+func NewService(repository Repository, hosts host.Service, records dns.Service) Service {
+	return &service{
+		repository: repository,
+		hosts:      hosts,
+		records:    records,
+	}
+}
+
+func (s *service) Insert(cname *model.Cname) error {
+	return s.repository.Transaction(func(repository Repository) error {
+		if err := s.check(repository, nil, cname); err != nil {
+			return err
+		}
+
+		return repository.Save(cname)
+	})
+}
+
+func (s *service) UpdateByAlias(alias string, cname *model.Cname) (current *model.Cname, err error) {
+	err = s.repository.Transaction(func(repository Repository) error {
+		current, err = repository.FindByAlias(alias)
+		if err != nil {
+			return err
+		}
+
+		if err := s.check(repository, current, cname); err != nil {
+			return err
+		}
+
+		if current == nil {
+			return repository.Save(cname)
+		}
+
+		return repository.Update(current, cname)
+	})
+	return current, err
+}
+
+// check rejects the CNAME when any of its aliases is used by another CNAME, other than the current
+// one, or when its target does not lead to a known name.
+func (s *service) check(repository Repository, current *model.Cname, cname *model.Cname) error {
+	all, err := repository.FindAll()
+	if err != nil {
+		return err
+	}
+
+	others := make([]model.Cname, 0, len(*all))
+	for _, other := range *all {
+		if current == nil || !current.Equal(other) {
+			others = append(others, other)
+		}
+	}
+
+	for _, alias := range cname.Aliases {
+		if slices.ContainsFunc(others, sameAlias(alias)) {
+			return DuplicatedEntryError{Alias: alias}
+		}
+	}
+
+	// Follow the chain of aliases up to the name it resolves to
+	target := cname.Target
+	visited := []string{}
+	for {
+		if cname.HasAlias(target) || slices.Contains(visited, strings.ToLower(target)) {
+			return LoopError{Alias: target}
+		}
+		visited = append(visited, strings.ToLower(target))
+
+		i := slices.IndexFunc(others, sameAlias(target))
+		if i < 0 {
+			break
+		}
+		target = others[i].Target
+	}
+
+	known, err := s.isKnown(target)
+	if err != nil {
+		return err
+	}
+	if !known {
+		return UnknownTargetError{Target: cname.Target}
+	}
+
+	return nil
+}
+
+// isKnown reports whether name is the host name of a static host, or any name of a host-record.
+func (s *service) isKnown(name string) (bool, error) {
+	h, err := s.hosts.FetchByHostName(name)
+	if err != nil || h != nil {
+		return h != nil, err
+	}
+
+	record, err := s.records.FetchByName(model.HostRecord, name)
+	return record != nil, err
+}
+
+func (s *service) Restore(data []byte) error {
+	return s.repository.Restore(data)
+}
+
+func (s *service) FetchAll() (*[]model.Cname, error) {
+	return s.repository.FindAll()
+}
+
+func (s *service) FetchByAlias(alias string) (*model.Cname, error) {
+	return s.repository.FindByAlias(alias)
+}
+
+func (s *service) RemoveByAlias(alias string) (*model.Cname, error) {
+	return s.repository.DeleteByAlias(alias)
+}
+
+// InUse returns the check failing when any of the names is the target of a CNAME of the repository,
+// which would be left dangling by removing the name, dnsmasq refusing to start with it.
+func InUse(repository Repository) dnsmasq.NamesInUse {
+	return func(names []string) error {
+		cnames, err := repository.FindAll()
+		if err != nil {
+			return err
+		}
+
+		for _, name := range names {
+			i := slices.IndexFunc(*cnames, sameTarget(name))
+			if i >= 0 {
+				return dnsmasq.NameInUseError{Name: name, Entry: (*cnames)[i].ToConfig()}
+			}
+		}
+
+		return nil
+	}
+}
+
+type DuplicatedEntryError struct {
+	Alias string
+}
+
+func (e DuplicatedEntryError) Error() string {
+	return fmt.Sprintf("Duplicated alias: %s", e.Alias)
+}
+
+// UnknownTargetError is returned when the target of a CNAME is neither a static host, a host-record
+// nor another alias leading to one of them.
+type UnknownTargetError struct {
+	Target string
+}
+
+func (e UnknownTargetError) Error() string {
+	return fmt.Sprintf("Unknown CNAME target: %s", e.Target)
+}
+
+// LoopError is returned when the target of a CNAME leads back to the alias.
+type LoopError struct {
+	Alias string
+}
+
+func (e LoopError) Error() string {
+	return fmt.Sprintf("CNAME loop through the alias: %s", e.Alias)
+}
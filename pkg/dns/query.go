@@ -0,0 +1,46 @@
+package dns
+
+import (
+	"path"
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+// Query selects DNS records. The zero value selects all the records.
+type Query struct {
+	// Type selects the records of a single type
+	Type model.DnsRecordType
+	// Name selects the records with any name matching the lowercase glob pattern
+	Name string
+}
+
+// Select returns the records matching the query, in their original order.
+func Select(records []model.DnsRecord, q Query) []model.DnsRecord {
+	selected := make([]model.DnsRecord, 0, len(records))
+	for _, record := range records {
+		if q.match(record) {
+			selected = append(selected, record)
+		}
+	}
+
+	return selected
+}
+
+func (q Query) match(record model.DnsRecord) bool {
+	if q.Type != "" && record.Type != q.Type {
+		return false
+	}
+
+	if q.Name == "" {
+		return true
+	}
+
+	for _, name := range record.Names {
+		if matched, _ := path.Match(q.Name, strings.ToLower(name)); matched {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,233 @@
+package dns
+
+import (
+	"errors"
+	"os"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"golang.org/x/exp/slog"
+)
+
+type Repository interface {
+	Delete(record *model.DnsRecord) (*model.DnsRecord, error)
+	DeleteByName(recordType model.DnsRecordType, name string) (*model.DnsRecord, error)
+	Find(record *model.DnsRecord) (*model.DnsRecord, error)
+	FindAll() (*[]model.DnsRecord, error)
+	FindByName(recordType model.DnsRecordType, name string) (*model.DnsRecord, error)
+	Save(record *model.DnsRecord) error
+	Update(current *model.DnsRecord, record *model.DnsRecord) error
+	// Restore replaces the whole DNS records file with data, which must only hold valid entries.
+	Restore(data []byte) error
+	// Transaction runs fn as a single critical section over the DNS records. All the changes made
+	// through the given Repository are written at once when fn succeeds, and discarded otherwise.
+	Transaction(fn func(Repository) error) error
+}
+
+var ErrRecordNotFound = errors.New("DNS record not found")
+
+type repository struct {
+	store *dnsmasq.Store[model.DnsRecord]
+	inUse dnsmasq.NamesInUse
+}
+
+// NewRepository creates the repository of the file, writing the changes through the writer. The
+// changes removing or renaming any host-record name still in use, as told by inUse, are rejected.
+func NewRepository(dnsRecordsFilePath string, fileMode os.FileMode, writer dnsmasq.Writer, inUse dnsmasq.NamesInUse) Repository {
+	return &repository{
+		store: dnsmasq.NewStore("DNS records", dnsRecordsFilePath, fileMode, writer, format),
+		inUse: inUse,
+	}
+}
+
+var format = dnsmasq.Format[model.DnsRecord]{
+	Parse:  parseLine,
+	Config: (*model.DnsRecord).ToConfig,
+	Merge:  (*model.DnsRecord).Merge,
+}
+
+func (r *repository) FindAll() (*[]model.DnsRecord, error) {
+	doc, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.FindAll()
+}
+
+func (r *repository) Find(record *model.DnsRecord) (*model.DnsRecord, error) {
+	doc, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.Find(record)
+}
+
+func (r *repository) FindByName(recordType model.DnsRecordType, name string) (*model.DnsRecord, error) {
+	doc, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.FindByName(recordType, name)
+}
+
+func (r *repository) Save(record *model.DnsRecord) error {
+	return r.Transaction(func(tx Repository) error {
+		return tx.Save(record)
+	})
+}
+
+func (r *repository) Update(current *model.DnsRecord, record *model.DnsRecord) error {
+	return r.Transaction(func(tx Repository) error {
+		return tx.Update(current, record)
+	})
+}
+
+func (r *repository) Delete(record *model.DnsRecord) (deleted *model.DnsRecord, err error) {
+	err = r.Transaction(func(tx Repository) error {
+		deleted, err = tx.Delete(record)
+		return err
+	})
+	return deleted, err
+}
+
+func (r *repository) DeleteByName(recordType model.DnsRecordType, name string) (deleted *model.DnsRecord, err error) {
+	err = r.Transaction(func(tx Repository) error {
+		deleted, err = tx.DeleteByName(recordType, name)
+		return err
+	})
+	return deleted, err
+}
+
+func (r *repository) Restore(data []byte) error {
+	return r.Transaction(func(tx Repository) error {
+		return tx.Restore(data)
+	})
+}
+
+func (r *repository) Transaction(fn func(Repository) error) error {
+	return r.store.Transaction(func(doc *dnsmasq.Document[model.DnsRecord]) error {
+		before := hostRecordNames(doc.Entries())
+		if err := fn(&document{doc: doc}); err != nil {
+			return err
+		}
+
+		if r.inUse == nil || !doc.Changed() {
+			return nil
+		}
+
+		removed := dnsmasq.RemovedNames(before, hostRecordNames(doc.Entries()))
+		if len(removed) == 0 {
+			return nil
+		}
+
+		return r.inUse(removed)
+	})
+}
+
+// hostRecordNames returns the names of the host-records, the only records a CNAME may target.
+func hostRecordNames(records []model.DnsRecord) []string {
+	names := []string{}
+	for _, record := range records {
+		if record.Type == model.HostRecord {
+			names = append(names, record.Names...)
+		}
+	}
+
+	return names
+}
+
+func (r *repository) load() (*document, error) {
+	doc, err := r.store.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	return &document{doc: doc}, nil
+}
+
+func parseLine(line string) (*model.DnsRecord, error) {
+	name, value, ok := dnsmasq.Directive(line)
+	if !ok || !model.IsDnsRecordType(name) {
+		slog.Debug("Skipping line", slog.String("line", line))
+		return nil, nil
+	}
+	slog.Debug("Parsing line", slog.String("line", line))
+
+	record := model.DnsRecord{}
+	err := record.FromConfig(model.DnsRecordType(name), value)
+	if err != nil {
+		slog.Error("Failed to parse DNS record entry",
+			slog.String("entry", line),
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return &record, nil
+}
+
+// document implements the Repository operations in memory over the parsed DNS records file, to be
+// used within a transaction.
+type document struct {
+	doc *dnsmasq.Document[model.DnsRecord]
+}
+
+func (d *document) FindAll() (*[]model.DnsRecord, error) {
+	records := d.doc.Entries()
+	return &records, nil
+}
+
+func (d *document) Find(record *model.DnsRecord) (*model.DnsRecord, error) {
+	return d.doc.Find(sameRecord(record)), nil
+}
+
+func (d *document) FindByName(recordType model.DnsRecordType, name string) (*model.DnsRecord, error) {
+	return d.doc.Find(sameName(recordType, name)), nil
+}
+
+func (d *document) Save(record *model.DnsRecord) error {
+	d.doc.Insert(record)
+	return nil
+}
+
+func (d *document) Update(current *model.DnsRecord, record *model.DnsRecord) error {
+	if !d.doc.Update(sameRecord(current), record) {
+		return ErrRecordNotFound
+	}
+
+	return nil
+}
+
+func (d *document) Delete(record *model.DnsRecord) (*model.DnsRecord, error) {
+	return d.doc.Delete(sameRecord(record)), nil
+}
+
+func (d *document) DeleteByName(recordType model.DnsRecordType, name string) (*model.DnsRecord, error) {
+	return d.doc.Delete(sameName(recordType, name)), nil
+}
+
+func (d *document) Restore(data []byte) error {
+	return d.doc.Restore(data)
+}
+
+// Transaction runs fn straight away, the document is already part of one.
+func (d *document) Transaction(fn func(Repository) error) error {
+	return fn(d)
+}
+
+type Filter func(model.DnsRecord) bool
+
+func sameRecord(record *model.DnsRecord) Filter {
+	return func(other model.DnsRecord) bool {
+		return record.Equal(other)
+	}
+}
+
+func sameName(recordType model.DnsRecordType, name string) Filter {
+	return func(other model.DnsRecord) bool {
+		return other.Type == recordType && name != "" && other.HasName(name)
+	}
+}
@@ -0,0 +1,100 @@
+package dns
+
+import (
+	"fmt"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+type Service interface {
+	Insert(record *model.DnsRecord) error
+	// UpdateByName replaces the record of the given type holding the name, or adds the record when
+	// there is none. It returns the replaced record, if any.
+	UpdateByName(recordType model.DnsRecordType, name string, record *model.DnsRecord) (*model.DnsRecord, error)
+	FetchAll() (*[]model.DnsRecord, error)
+	FetchByName(recordType model.DnsRecordType, name string) (*model.DnsRecord, error)
+	RemoveByName(recordType model.DnsRecordType, name string) (*model.DnsRecord, error)
+	// Restore replaces all the DNS records with the ones of a previous version of the DNS records file.
+	Restore(data []byte) error
+}
+
+type service struct {
+	repository Repository
+}
+
+func NewService(repository Repository) Service {
+	return &service{
+		repository: repository,
+	}
+}
+
+func (s *service) Insert(record *model.DnsRecord) error {
+	return s.repository.Transaction(func(repository Repository) error {
+		if err := checkConflicts(repository, nil, record); err != nil {
+			return err
+		}
+
+		return repository.Save(record)
+	})
+}
+
+func (s *service) UpdateByName(recordType model.DnsRecordType, name string, record *model.DnsRecord) (current *model.DnsRecord, err error) {
+	err = s.repository.Transaction(func(repository Repository) error {
+		current, err = repository.FindByName(recordType, name)
+		if err != nil {
+			return err
+		}
+
+		if err := checkConflicts(repository, current, record); err != nil {
+			return err
+		}
+
+		if current == nil {
+			return repository.Save(record)
+		}
+
+		return repository.Update(current, record)
+	})
+	return current, err
+}
+
+// checkConflicts rejects the record when any of its names is already used by another record, other
+// than the current one, of the same type.
+func checkConflicts(repository Repository, current *model.DnsRecord, record *model.DnsRecord) error {
+	for _, name := range record.Names {
+		other, err := repository.FindByName(record.Type, name)
+		if err != nil {
+			return err
+		}
+		if other != nil && (current == nil || !current.Equal(*other)) {
+			return DuplicatedEntryError{Type: record.Type, Name: name}
+		}
+	}
+
+	return nil
+}
+
+func (s *service) Restore(data []byte) error {
+	return s.repository.Restore(data)
+}
+
+func (s *service) FetchAll() (*[]model.DnsRecord, error) {
+	return s.repository.FindAll()
+}
+
+func (s *service) FetchByName(recordType model.DnsRecordType, name string) (*model.DnsRecord, error) {
+	return s.repository.FindByName(recordType, name)
+}
+
+func (s *service) RemoveByName(recordType model.DnsRecordType, name string) (*model.DnsRecord, error) {
+	return s.repository.DeleteByName(recordType, name)
+}
+
+type DuplicatedEntryError struct {
+	Type model.DnsRecordType
+	Name string
+}
+
+func (e DuplicatedEntryError) Error() string {
+	return fmt.Sprintf("Duplicated %s name: %s", e.Type, e.Name)
+}
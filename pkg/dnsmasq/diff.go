@@ -0,0 +1,214 @@
+package dnsmasq
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// diffContext is the number of unchanged lines around the changes of a hunk
+const diffContext = 3
+
+type diffLine struct {
+	// op is ' ' for an unchanged line, '-' for a removed line and '+' for an added line
+	op   byte
+	text string
+}
+
+// Diff returns the unified diff between the contents of the file at path, or an empty string when
+// they are the same.
+func Diff(path string, before []byte, after []byte) string {
+	lines := diffLines(splitLines(string(before)), splitLines(string(after)))
+
+	diff := strings.Builder{}
+	for start := 0; start < len(lines); {
+		first := nextChange(lines, start)
+		if first < 0 {
+			break
+		}
+
+		// Extend the hunk while the next change is close enough to share the context lines
+		last := first
+		for next := nextChange(lines, last+1); next >= 0 && next-last <= 2*diffContext; next = nextChange(lines, last+1) {
+			last = next
+		}
+
+		begin := first - diffContext
+		if begin < start {
+			begin = start
+		}
+		end := last + diffContext + 1
+		if end > len(lines) {
+			end = len(lines)
+		}
+		if diff.Len() == 0 {
+			name := strings.TrimPrefix(filepath.Clean(path), "/")
+			fmt.Fprintf(&diff, "--- a/%s\n+++ b/%s\n", name, name)
+		}
+		writeHunk(&diff, lines, begin, end)
+		start = end
+	}
+
+	return diff.String()
+}
+
+// splitLines splits the text into its lines, each keeping its newline, so the last line lacking
+// one differs from the same line ending with it.
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// diffLines returns the shortest edit script from a to b, through their longest common subsequence.
+func diffLines(a []string, b []string) []diffLine {
+	lines := make([]diffLine, 0, len(a)+len(b))
+	return appendDiff(lines, a, b)
+}
+
+// appendDiff appends the edit script from a to b, found by Hirschberg's algorithm: a is split in
+// half, and b where the longest common subsequences of both halves add up to the longest one, so
+// each half is diffed on its own, in linear space.
+func appendDiff(lines []diffLine, a []string, b []string) []diffLine {
+	// The lines around the changes are most often left untouched
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+
+	common := a[len(a)-suffix:]
+	lines = appendLines(lines, ' ', a[:prefix])
+	a, b = a[prefix:len(a)-suffix], b[prefix:len(b)-suffix]
+
+	switch {
+	case len(a) == 0:
+		lines = appendLines(lines, '+', b)
+	case len(b) == 0:
+		lines = appendLines(lines, '-', a)
+	case len(a) == 1:
+		if i := slices.Index(b, a[0]); i >= 0 {
+			lines = appendLines(lines, '+', b[:i])
+			lines = append(lines, diffLine{' ', a[0]})
+			lines = appendLines(lines, '+', b[i+1:])
+		} else {
+			lines = append(lines, diffLine{'-', a[0]})
+			lines = appendLines(lines, '+', b)
+		}
+	default:
+		half := len(a) / 2
+		forward := lcsLengths(a[:half], b)
+		backward := lcsLengths(reversed(a[half:]), reversed(b))
+
+		split := 0
+		for j := range forward {
+			if forward[j]+backward[len(b)-j] > forward[split]+backward[len(b)-split] {
+				split = j
+			}
+		}
+
+		lines = appendDiff(lines, a[:half], b[:split])
+		lines = appendDiff(lines, a[half:], b[split:])
+	}
+
+	return appendLines(lines, ' ', common)
+}
+
+// lcsLengths returns the length of the longest common subsequence of a with every prefix of b.
+func lcsLengths(a []string, b []string) []int {
+	lengths := make([]int, len(b)+1)
+	for i := range a {
+		// diagonal is the length for the previous line of a and the previous prefix of b
+		diagonal := 0
+		for j := range b {
+			next := lengths[j+1]
+			if a[i] == b[j] {
+				lengths[j+1] = diagonal + 1
+			} else if lengths[j] > lengths[j+1] {
+				lengths[j+1] = lengths[j]
+			}
+			diagonal = next
+		}
+	}
+
+	return lengths
+}
+
+func reversed(lines []string) []string {
+	r := make([]string, len(lines))
+	for i, line := range lines {
+		r[len(lines)-1-i] = line
+	}
+
+	return r
+}
+
+func appendLines(lines []diffLine, op byte, texts []string) []diffLine {
+	for _, text := range texts {
+		lines = append(lines, diffLine{op, text})
+	}
+
+	return lines
+}
+
+func nextChange(lines []diffLine, from int) int {
+	for i := from; i < len(lines); i++ {
+		if lines[i].op != ' ' {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func writeHunk(diff *strings.Builder, lines []diffLine, begin int, end int) {
+	// Count the lines of each side up to the hunk, and within it
+	oldStart, newStart := 1, 1
+	for _, line := range lines[:begin] {
+		if line.op != '+' {
+			oldStart++
+		}
+		if line.op != '-' {
+			newStart++
+		}
+	}
+
+	oldLen, newLen := 0, 0
+	for _, line := range lines[begin:end] {
+		if line.op != '+' {
+			oldLen++
+		}
+		if line.op != '-' {
+			newLen++
+		}
+	}
+
+	// An empty side refers to the line before the hunk
+	if oldLen == 0 {
+		oldStart--
+	}
+	if newLen == 0 {
+		newStart--
+	}
+
+	fmt.Fprintf(diff, "@@ -%d,%d +%d,%d @@\n", oldStart, oldLen, newStart, newLen)
+	for _, line := range lines[begin:end] {
+		diff.WriteByte(line.op)
+		diff.WriteString(line.text)
+		if !strings.HasSuffix(line.text, "\n") {
+			diff.WriteString("\n\\ No newline at end of file\n")
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package dnsmasq
+
+import (
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          string
+	}{
+		{
+			name:   "unchanged",
+			before: "a\nb\n",
+			after:  "a\nb\n",
+		},
+		{
+			name:   "added to an empty file",
+			before: "",
+			after:  "a\n",
+			want:   "--- a/file.conf\n+++ b/file.conf\n@@ -0,0 +1,1 @@\n+a\n",
+		},
+		{
+			name:   "replaced",
+			before: "a\nb\nc\n",
+			after:  "a\nx\nc\n",
+			want:   "--- a/file.conf\n+++ b/file.conf\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n",
+		},
+		{
+			name:   "distant changes",
+			before: "1\n2\n3\n4\n5\n6\n7\n8\n9\n10\n",
+			after:  "0\n2\n3\n4\n5\n6\n7\n8\n9\n11\n",
+			want: "--- a/file.conf\n+++ b/file.conf\n@@ -1,4 +1,4 @@\n-1\n+0\n 2\n 3\n 4\n" +
+				"@@ -7,4 +7,4 @@\n 7\n 8\n 9\n-10\n+11\n",
+		},
+		{
+			name:   "newline added at end of file",
+			before: "a\nb",
+			after:  "a\nb\nc\n",
+			want: "--- a/file.conf\n+++ b/file.conf\n@@ -1,2 +1,3 @@\n a\n-b\n\\ No newline at end of file\n" +
+				"+b\n+c\n",
+		},
+		{
+			name:   "unchanged line without newline",
+			before: "a\nb",
+			after:  "x\nb",
+			want:   "--- a/file.conf\n+++ b/file.conf\n@@ -1,2 +1,2 @@\n-a\n+x\n b\n\\ No newline at end of file\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Diff("/file.conf", []byte(tt.before), []byte(tt.after)); got != tt.want {
+				t.Errorf("Diff() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestDiffLines checks the edit scripts of random files against their longest common subsequence.
+func TestDiffLines(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	randomLines := func() []string {
+		lines := make([]string, random.Intn(30))
+		for i := range lines {
+			lines[i] = string(rune('a' + random.Intn(4)))
+		}
+		return lines
+	}
+
+	for n := 0; n < 500; n++ {
+		a, b := randomLines(), randomLines()
+		lines := diffLines(a, b)
+
+		var before, after []string
+		common := 0
+		for _, line := range lines {
+			if line.op != '+' {
+				before = append(before, line.text)
+			}
+			if line.op != '-' {
+				after = append(after, line.text)
+			}
+			if line.op == ' ' {
+				common++
+			}
+		}
+
+		if strings.Join(before, "") != strings.Join(a, "") || strings.Join(after, "") != strings.Join(b, "") {
+			t.Fatalf("diffLines(%q, %q) = %v, which does not turn one into the other", a, b, lines)
+		}
+		if want := lcsLength(a, b); common != want {
+			t.Fatalf("diffLines(%q, %q) keeps %d lines, want %d", a, b, common, want)
+		}
+	}
+}
+
+// lcsLength returns the length of the longest common subsequence of a and b, by the plain
+// quadratic table.
+func lcsLength(a []string, b []string) int {
+	table := make([][]int, len(a)+1)
+	for i := range table {
+		table[i] = make([]int, len(b)+1)
+	}
+	for i := range a {
+		for j := range b {
+			switch {
+			case a[i] == b[j]:
+				table[i+1][j+1] = table[i][j] + 1
+			case table[i][j+1] > table[i+1][j]:
+				table[i+1][j+1] = table[i][j+1]
+			default:
+				table[i+1][j+1] = table[i+1][j]
+			}
+		}
+	}
+
+	return table[len(a)][len(b)]
+}
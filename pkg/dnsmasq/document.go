@@ -0,0 +1,193 @@
+package dnsmasq
+
+import (
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Format tells how the entries of a configuration file are read and written.
+type Format[T any] struct {
+	// Parse returns the entry held by the line, or nil when the line holds none.
+	Parse func(line string) (*T, error)
+	// Config returns the lines of the entry, separated by newlines.
+	Config func(entry *T) string
+	// Merge folds the entry into the previous one, reporting whether both are the lines of a
+	// single entry. It is optional, an entry is a single line otherwise.
+	Merge func(previous *T, entry *T) bool
+}
+
+// Document keeps the entries parsed from a configuration file along with the file they were read
+// from, so every change is applied on the lines holding the entry, leaving all the other lines
+// untouched.
+type Document[T any] struct {
+	format  Format[T]
+	file    *File
+	entries []T
+	lines   [][]int
+	changed bool
+}
+
+func ParseDocument[T any](data []byte, format Format[T]) (*Document[T], error) {
+	d := &Document[T]{format: format, file: Parse(data)}
+	for i := 0; i < d.file.Len(); i++ {
+		entry, err := format.Parse(d.file.Line(i))
+		if err != nil {
+			return nil, err
+		}
+		if entry == nil {
+			continue
+		}
+
+		if j := d.merge(entry); j >= 0 {
+			d.lines[j] = append(d.lines[j], i)
+			continue
+		}
+
+		d.entries = append(d.entries, *entry)
+		d.lines = append(d.lines, []int{i})
+	}
+
+	return d, nil
+}
+
+// merge folds the entry into any previous one, returning its index, or -1 when there is none.
+func (d *Document[T]) merge(entry *T) int {
+	if d.format.Merge == nil {
+		return -1
+	}
+
+	for i := range d.entries {
+		if d.format.Merge(&d.entries[i], entry) {
+			return i
+		}
+	}
+
+	return -1
+}
+
+func (d *Document[T]) Bytes() []byte {
+	return d.file.Bytes()
+}
+
+// Changed reports whether any entry was changed since the file was read.
+func (d *Document[T]) Changed() bool {
+	return d.changed
+}
+
+func (d *Document[T]) Entries() []T {
+	return slices.Clone(d.entries)
+}
+
+// Find returns a copy of the first entry matching the filter, if any.
+func (d *Document[T]) Find(filter func(T) bool) *T {
+	i := slices.IndexFunc(d.entries, filter)
+	if i < 0 {
+		return nil
+	}
+
+	entry := d.entries[i]
+	return &entry
+}
+
+// Insert places the new entry right after the last entry, or at the end of the file when there is
+// none.
+func (d *Document[T]) Insert(entry *T) {
+	line := d.file.Len()
+	if len(d.lines) > 0 {
+		line = 0
+		for _, lines := range d.lines {
+			for _, l := range lines {
+				if l >= line {
+					line = l + 1
+				}
+			}
+		}
+	}
+
+	d.entries = append(d.entries, *entry)
+	d.lines = append(d.lines, nil)
+	d.write(len(d.entries)-1, entry, line)
+}
+
+// Update replaces the first entry matching the filter, reporting whether there was any.
+func (d *Document[T]) Update(filter func(T) bool, entry *T) bool {
+	i := slices.IndexFunc(d.entries, filter)
+	if i < 0 {
+		return false
+	}
+
+	lines := d.lines[i]
+	at := lines[0]
+	for j := len(lines) - 1; j >= 0; j-- {
+		d.removeLine(lines[j])
+	}
+
+	d.entries[i] = *entry
+	d.lines[i] = nil
+	d.write(i, entry, at)
+
+	return true
+}
+
+// Delete removes the first entry matching the filter, returning it, if any.
+func (d *Document[T]) Delete(filter func(T) bool) *T {
+	i := slices.IndexFunc(d.entries, filter)
+	if i < 0 {
+		return nil
+	}
+
+	entry := d.entries[i]
+	lines := d.lines[i]
+	d.entries = slices.Delete(d.entries, i, i+1)
+	d.lines = slices.Delete(d.lines, i, i+1)
+	for j := len(lines) - 1; j >= 0; j-- {
+		d.removeLine(lines[j])
+	}
+	d.changed = true
+
+	return &entry
+}
+
+// Restore replaces the whole file with data, which must only hold valid entries.
+func (d *Document[T]) Restore(data []byte) error {
+	doc, err := ParseDocument(data, d.format)
+	if err != nil {
+		return err
+	}
+
+	*d = *doc
+	d.changed = true
+
+	return nil
+}
+
+// write inserts the lines of the i-th entry at the given line.
+func (d *Document[T]) write(i int, entry *T, at int) {
+	for j, line := range strings.Split(d.format.Config(entry), "\n") {
+		d.insertLine(at+j, line)
+		d.lines[i] = append(d.lines[i], at+j)
+	}
+	d.changed = true
+}
+
+func (d *Document[T]) insertLine(index int, line string) {
+	d.file.Insert(index, line)
+	d.shift(index, 1)
+}
+
+func (d *Document[T]) removeLine(index int) {
+	d.file.Remove(index)
+	d.shift(index, -1)
+}
+
+// shift moves the lines of the entries following the given line.
+func (d *Document[T]) shift(index int, offset int) {
+	for _, lines := range d.lines {
+		for j := range lines {
+			if lines[j] >= index && (offset > 0 || lines[j] > index) {
+				lines[j] += offset
+			}
+		}
+	}
+}
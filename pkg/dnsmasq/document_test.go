@@ -0,0 +1,127 @@
+package dnsmasq
+
+import (
+	"strings"
+	"testing"
+)
+
+// entry is a test entry written as one "entry=<name>" line per value
+type entry struct {
+	name   string
+	values []string
+}
+
+var testFormat = Format[entry]{
+	Parse: func(line string) (*entry, error) {
+		name, value, ok := Directive(line)
+		if !ok || name != "entry" {
+			return nil, nil
+		}
+		key, v, _ := strings.Cut(value, ":")
+		return &entry{name: key, values: []string{v}}, nil
+	},
+	Config: func(e *entry) string {
+		lines := make([]string, 0, len(e.values))
+		for _, v := range e.values {
+			lines = append(lines, "entry="+e.name+":"+v)
+		}
+		return strings.Join(lines, "\n")
+	},
+	Merge: func(previous *entry, e *entry) bool {
+		if previous.name != e.name {
+			return false
+		}
+		previous.values = append(previous.values, e.values...)
+		return true
+	},
+}
+
+func named(name string) func(entry) bool {
+	return func(e entry) bool {
+		return e.name == name
+	}
+}
+
+func TestDocument(t *testing.T) {
+	const file = "# header\nentry=a:1\nother=x\nentry=b:1\nentry=a:2\n# footer\n"
+
+	tests := []struct {
+		name string
+		fn   func(d *Document[entry])
+		want string
+	}{
+		{
+			name: "unchanged",
+			fn:   func(d *Document[entry]) {},
+			want: file,
+		},
+		{
+			name: "insert after the last line of the entries",
+			fn: func(d *Document[entry]) {
+				d.Insert(&entry{name: "c", values: []string{"1", "2"}})
+			},
+			want: "# header\nentry=a:1\nother=x\nentry=b:1\nentry=a:2\nentry=c:1\nentry=c:2\n# footer\n",
+		},
+		{
+			name: "update an entry spanning several lines into a single line",
+			fn: func(d *Document[entry]) {
+				d.Update(named("a"), &entry{name: "a", values: []string{"3"}})
+			},
+			want: "# header\nentry=a:3\nother=x\nentry=b:1\n# footer\n",
+		},
+		{
+			name: "update a single line entry into several lines",
+			fn: func(d *Document[entry]) {
+				d.Update(named("b"), &entry{name: "b", values: []string{"2", "3"}})
+				d.Insert(&entry{name: "c", values: []string{"1"}})
+			},
+			want: "# header\nentry=a:1\nother=x\nentry=b:2\nentry=b:3\nentry=a:2\nentry=c:1\n# footer\n",
+		},
+		{
+			name: "delete an entry spanning several lines",
+			fn: func(d *Document[entry]) {
+				d.Delete(named("a"))
+				d.Update(named("b"), &entry{name: "b", values: []string{"2"}})
+			},
+			want: "# header\nother=x\nentry=b:2\n# footer\n",
+		},
+		{
+			name: "delete every entry then insert",
+			fn: func(d *Document[entry]) {
+				d.Delete(named("a"))
+				d.Delete(named("b"))
+				d.Insert(&entry{name: "c", values: []string{"1"}})
+			},
+			want: "# header\nother=x\n# footer\nentry=c:1\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d, err := ParseDocument([]byte(file), testFormat)
+			if err != nil {
+				t.Fatalf("ParseDocument failed: %v", err)
+			}
+
+			tt.fn(d)
+			if got := string(d.Bytes()); got != tt.want {
+				t.Errorf("Bytes() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDocumentMerge(t *testing.T) {
+	d, err := ParseDocument([]byte("entry=a:1\nentry=b:1\nentry=a:2\n"), testFormat)
+	if err != nil {
+		t.Fatalf("ParseDocument failed: %v", err)
+	}
+
+	entries := d.Entries()
+	if len(entries) != 2 {
+		t.Fatalf("Entries() = %v, want 2 entries", entries)
+	}
+	if a := d.Find(named("a")); a == nil || strings.Join(a.values, ",") != "1,2" {
+		t.Errorf("Find(a) = %v, want values 1,2", a)
+	}
+}
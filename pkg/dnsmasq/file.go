@@ -0,0 +1,78 @@
+package dnsmasq
+
+import (
+	"strings"
+)
+
+// File is a lossless representation of a dnsmasq configuration file. Every line is kept verbatim,
+// so comments, blank lines and directives that are not managed by this service are written back
+// exactly as they were read.
+type File struct {
+	lines           []string
+	trailingNewline bool
+}
+
+func NewFile() *File {
+	return &File{trailingNewline: true}
+}
+
+func Parse(data []byte) *File {
+	if len(data) == 0 {
+		return NewFile()
+	}
+
+	lines := strings.Split(string(data), "\n")
+	trailingNewline := lines[len(lines)-1] == ""
+	if trailingNewline {
+		lines = lines[:len(lines)-1]
+	}
+
+	return &File{
+		lines:           lines,
+		trailingNewline: trailingNewline,
+	}
+}
+
+func (f *File) Bytes() []byte {
+	content := strings.Join(f.lines, "\n")
+	if f.trailingNewline && len(f.lines) > 0 {
+		content += "\n"
+	}
+
+	return []byte(content)
+}
+
+func (f *File) Len() int {
+	return len(f.lines)
+}
+
+func (f *File) Line(index int) string {
+	return f.lines[index]
+}
+
+func (f *File) Set(index int, line string) {
+	f.lines[index] = line
+}
+
+// Insert adds a new line at the given index, shifting the following lines down.
+func (f *File) Insert(index int, line string) {
+	f.lines = append(f.lines, "")
+	copy(f.lines[index+1:], f.lines[index:])
+	f.lines[index] = line
+}
+
+func (f *File) Remove(index int) {
+	f.lines = append(f.lines[:index], f.lines[index+1:]...)
+}
+
+// Directive splits a configuration line into its option name and value. Comments and blank lines
+// are not directives.
+func Directive(line string) (name string, value string, ok bool) {
+	line = strings.TrimSpace(line)
+	if line == "" || strings.HasPrefix(line, "#") {
+		return "", "", false
+	}
+
+	name, value, _ = strings.Cut(line, "=")
+	return strings.TrimSpace(name), strings.TrimSpace(value), true
+}
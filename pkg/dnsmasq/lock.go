@@ -0,0 +1,42 @@
+package dnsmasq
+
+import (
+	"os"
+	"syscall"
+)
+
+// Lock takes an exclusive advisory lock (flock) on the file at path, blocking until it is
+// available, and returns the function that releases it. Since WriteFile replaces the file instead
+// of rewriting it, the lock is retried whenever the file was replaced while waiting for it.
+func Lock(path string) (func(), error) {
+	for {
+		file, err := os.Open(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := syscall.Flock(int(file.Fd()), syscall.LOCK_EX); err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		locked, err := file.Stat()
+		if err != nil {
+			file.Close()
+			return nil, err
+		}
+
+		current, err := os.Stat(path)
+		if err == nil && os.SameFile(locked, current) {
+			return func() {
+				syscall.Flock(int(file.Fd()), syscall.LOCK_UN)
+				file.Close()
+			}, nil
+		}
+
+		file.Close()
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+	}
+}
@@ -0,0 +1,35 @@
+package dnsmasq
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// NamesInUse fails when any of the names is still in use by other entries, which would be left
+// dangling by removing or renaming them.
+type NamesInUse func(names []string) error
+
+// NameInUseError is returned when a change removes or renames a name still in use.
+type NameInUseError struct {
+	Name string
+	// Entry is the configuration of the entry using the name
+	Entry string
+}
+
+func (e NameInUseError) Error() string {
+	return fmt.Sprintf("name in use by %s: %s", e.Entry, e.Name)
+}
+
+// RemovedNames returns the names of before missing from after, case insensitively.
+func RemovedNames(before []string, after []string) []string {
+	removed := []string{}
+	for _, name := range before {
+		if !slices.ContainsFunc(after, func(other string) bool { return strings.EqualFold(name, other) }) {
+			removed = append(removed, name)
+		}
+	}
+
+	return removed
+}
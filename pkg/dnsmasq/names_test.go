@@ -0,0 +1,28 @@
+package dnsmasq
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRemovedNames(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after []string
+		want          string
+	}{
+		{name: "unchanged", before: []string{"nas", "printer"}, after: []string{"printer", "nas"}},
+		{name: "removed", before: []string{"nas", "printer"}, after: []string{"printer"}, want: "nas"},
+		{name: "renamed", before: []string{"nas"}, after: []string{"files"}, want: "nas"},
+		{name: "case only", before: []string{"nas"}, after: []string{"NAS"}},
+		{name: "added", before: []string{}, after: []string{"nas"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := strings.Join(RemovedNames(tt.before, tt.after), ","); got != tt.want {
+				t.Errorf("RemovedNames() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,178 @@
+package dnsmasq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// Reloader makes dnsmasq pick up the changes made to its configuration files.
+type Reloader interface {
+	Reload() error
+}
+
+type ReloaderFunc func() error
+
+func (f ReloaderFunc) Reload() error {
+	return f()
+}
+
+var ErrInvalidPidFile = errors.New("invalid pid file")
+
+// commandTimeout bounds how long a reload command may run
+const commandTimeout = 30 * time.Second
+
+// NewSignalReloader sends SIGHUP to the dnsmasq process whose PID is in the pid file. Note that
+// dnsmasq only rereads its hosts files (/etc/hosts, addn-hosts), dhcp-hostsfile, dhcp-optsfile and
+// the leases on SIGHUP, but not its main configuration nor the files of its conf-dir.
+func NewSignalReloader(pidFile string) Reloader {
+	return ReloaderFunc(func() error {
+		pid, err := ReadPidFile(pidFile)
+		if err != nil {
+			return err
+		}
+
+		return syscall.Kill(pid, syscall.SIGHUP)
+	})
+}
+
+// NewCommandReloader runs the command through the shell, e.g. "systemctl restart dnsmasq".
+func NewCommandReloader(command string) Reloader {
+	return ReloaderFunc(func() error {
+		ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+		defer cancel()
+
+		output, err := exec.CommandContext(ctx, "/bin/sh", "-c", command).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s: %w: %s", command, err, strings.TrimSpace(string(output)))
+		}
+
+		return nil
+	})
+}
+
+// ReadPidFile returns the PID held by the pid file.
+func ReadPidFile(pidFile string) (int, error) {
+	data, err := os.ReadFile(pidFile)
+	if err != nil {
+		return 0, err
+	}
+
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || pid <= 0 {
+		return 0, fmt.Errorf("%w: %s", ErrInvalidPidFile, pidFile)
+	}
+
+	return pid, nil
+}
+
+// Scheduler debounces the reloads, so a burst of changes is picked up by dnsmasq at once, after
+// the changes settle for the given delay. Every change is numbered by a generation, which can be
+// waited for to learn whether the change was reloaded.
+type Scheduler struct {
+	reloader Reloader
+	delay    time.Duration
+
+	mutex     sync.Mutex
+	reloaded  *sync.Cond
+	scheduled uint64
+	done      uint64
+	err       error
+	timer     *time.Timer
+
+	// running serializes the reloads
+	running sync.Mutex
+}
+
+func NewScheduler(reloader Reloader, delay time.Duration) *Scheduler {
+	s := &Scheduler{
+		reloader: reloader,
+		delay:    delay,
+	}
+	s.reloaded = sync.NewCond(&s.mutex)
+
+	return s
+}
+
+// Writer chains a writer that schedules a reload after every successful write.
+func (s *Scheduler) Writer(next Writer) Writer {
+	return WriterFunc(func(path string, data []byte, mode os.FileMode) error {
+		if err := next.WriteFile(path, data, mode); err != nil {
+			return err
+		}
+
+		s.Schedule()
+		return nil
+	})
+}
+
+// Schedule requests a reload, postponing any reload not started yet.
+func (s *Scheduler) Schedule() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.scheduled++
+	if s.timer == nil {
+		s.timer = time.AfterFunc(s.delay, s.reload)
+	} else {
+		s.timer.Reset(s.delay)
+	}
+}
+
+// Generation returns the generation of the last scheduled reload.
+func (s *Scheduler) Generation() uint64 {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.scheduled
+}
+
+// Wait blocks until the changes up to the given generation are reloaded, and returns the result of
+// the last reload.
+func (s *Scheduler) Wait(generation uint64) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for s.done < generation {
+		s.reloaded.Wait()
+	}
+
+	return s.err
+}
+
+func (s *Scheduler) reload() {
+	s.running.Lock()
+	defer s.running.Unlock()
+
+	s.mutex.Lock()
+	generation := s.scheduled
+	pending := generation > s.done
+	s.mutex.Unlock()
+
+	if !pending {
+		return
+	}
+
+	err := s.reloader.Reload()
+	if err != nil {
+		slog.Error("Error reloading dnsmasq", slog.String("error", err.Error()))
+	} else {
+		slog.Debug("dnsmasq reloaded", slog.Uint64("generation", generation))
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.done = generation
+	s.err = err
+	s.reloaded.Broadcast()
+}
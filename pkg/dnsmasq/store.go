@@ -0,0 +1,183 @@
+package dnsmasq
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"sync"
+
+	"golang.org/x/exp/slog"
+)
+
+// Store reads and writes the entries of a configuration file, running the changes as transactions.
+type Store[T any] struct {
+	// name names the file in the logs
+	name   string
+	path   string
+	mode   os.FileMode
+	writer Writer
+	format Format[T]
+	mutex  sync.Mutex
+}
+
+// NewStore creates the store of the file, writing the changes through the writer.
+func NewStore[T any](name string, path string, mode os.FileMode, writer Writer, format Format[T]) *Store[T] {
+	return &Store[T]{
+		name:   name,
+		path:   path,
+		mode:   mode,
+		writer: writer,
+		format: format,
+	}
+}
+
+// Load reads and parses the file.
+func (s *Store[T]) Load() (*Document[T], error) {
+	data, err := s.Read()
+	if err != nil {
+		return nil, err
+	}
+
+	return s.Parse(data)
+}
+
+// Read returns the content of the file, a missing file holds no entries.
+func (s *Store[T]) Read() ([]byte, error) {
+	data, err := os.ReadFile(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("Error reading the "+s.name+" file",
+			slog.String("file", s.path),
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return data, nil
+}
+
+func (s *Store[T]) Parse(data []byte) (*Document[T], error) {
+	return ParseDocument(data, s.format)
+}
+
+// Transaction runs fn as a single critical section over the file. All the changes made to the
+// document are written at once when fn succeeds, and discarded otherwise.
+func (s *Store[T]) Transaction(fn func(*Document[T]) error) error {
+	// The mutex serializes the transactions within this process, while the file lock serializes
+	// them with any other tool editing the same file.
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if unlock != nil {
+			unlock()
+		}
+	}()
+
+	doc, err := s.Load()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(doc); err != nil {
+		return err
+	}
+
+	if !doc.Changed() {
+		return nil
+	}
+
+	// A missing file is only created, and locked, once there are changes to write into it, so the
+	// transactions making no change do not touch the filesystem
+	if unlock == nil {
+		if err := s.create(); err != nil {
+			return err
+		}
+		if unlock, err = s.lock(); err != nil {
+			return err
+		}
+	}
+
+	return s.save(doc)
+}
+
+// lock takes the file lock, returning a nil unlock function when the file is missing, which holds
+// no entries to guard.
+func (s *Store[T]) lock() (func(), error) {
+	unlock, err := Lock(s.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		slog.Error("Error locking the "+s.name+" file",
+			slog.String("file", s.path),
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	return unlock, nil
+}
+
+// DryRun runs fn as Transaction does, but always discards the changes, returning the unified diff
+// of the file they would have made.
+func (s *Store[T]) DryRun(fn func(*Document[T]) error) (string, error) {
+	data, err := s.Read()
+	if err != nil {
+		return "", err
+	}
+
+	doc, err := s.Parse(data)
+	if err != nil {
+		return "", err
+	}
+
+	if err := fn(doc); err != nil {
+		return "", err
+	}
+
+	return Diff(s.path, data, doc.Bytes()), nil
+}
+
+// create creates the missing file with the configured mode, which an existing file does not take.
+func (s *Store[T]) create() error {
+	file, err := os.OpenFile(s.path, os.O_RDONLY|os.O_CREATE|os.O_EXCL, s.mode)
+	if errors.Is(err, fs.ErrExist) {
+		return nil
+	}
+	if err == nil {
+		err = file.Close()
+	}
+	if err == nil {
+		// The mode given to OpenFile is masked by the umask
+		err = os.Chmod(s.path, s.mode)
+	}
+	if err != nil {
+		slog.Error("Error creating the "+s.name+" file",
+			slog.String("file", s.path),
+			slog.String("error", err.Error()),
+		)
+		return err
+	}
+
+	return nil
+}
+
+func (s *Store[T]) save(doc *Document[T]) error {
+	err := s.writer.WriteFile(s.path, doc.Bytes(), s.mode)
+	if err != nil {
+		slog.Error("Error writing into the "+s.name+" file",
+			slog.String("file", s.path),
+			slog.String("error", err.Error()),
+		)
+		return err
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+package dnsmasq
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+)
+
+// inPlaceWriter rewrites the files in place, so they keep the mode they were created with
+var inPlaceWriter = WriterFunc(os.WriteFile)
+
+func TestStoreMissingFile(t *testing.T) {
+	tests := []struct {
+		name     string
+		run      func(s *Store[entry]) error
+		wantFile bool
+	}{
+		{
+			name: "no change",
+			run: func(s *Store[entry]) error {
+				return s.Transaction(func(d *Document[entry]) error { return nil })
+			},
+			wantFile: false,
+		},
+		{
+			name: "failed",
+			run: func(s *Store[entry]) error {
+				err := s.Transaction(func(d *Document[entry]) error {
+					d.Insert(&entry{name: "a", values: []string{"1"}})
+					return errors.New("failed")
+				})
+				if err == nil {
+					return errors.New("want the error of the transaction")
+				}
+				return nil
+			},
+			wantFile: false,
+		},
+		{
+			name: "dry run",
+			run: func(s *Store[entry]) error {
+				_, err := s.DryRun(func(d *Document[entry]) error {
+					d.Insert(&entry{name: "a", values: []string{"1"}})
+					return nil
+				})
+				return err
+			},
+			wantFile: false,
+		},
+		{
+			name: "changed",
+			run: func(s *Store[entry]) error {
+				return s.Transaction(func(d *Document[entry]) error {
+					d.Insert(&entry{name: "a", values: []string{"1"}})
+					return nil
+				})
+			},
+			wantFile: true,
+		},
+	}
+
+	// The mode of the created file must not be masked by the umask
+	defer syscall.Umask(syscall.Umask(0077))
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "entries.conf")
+			s := NewStore("test", path, 0664, inPlaceWriter, testFormat)
+
+			if err := tt.run(s); err != nil {
+				t.Fatalf("run failed: %v", err)
+			}
+
+			info, err := os.Stat(path)
+			if !tt.wantFile {
+				if !errors.Is(err, fs.ErrNotExist) {
+					t.Errorf("Stat() = %v, want the file missing", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Stat() failed: %v", err)
+			}
+			if info.Mode().Perm() != 0664 {
+				t.Errorf("mode = %v, want %v", info.Mode().Perm(), fs.FileMode(0664))
+			}
+		})
+	}
+}
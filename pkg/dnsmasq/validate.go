@@ -0,0 +1,79 @@
+package dnsmasq
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// StagingFilePlaceholder is replaced by the path of the staging file in the validator command.
+const StagingFilePlaceholder = "{file}"
+
+// ValidationError is returned when the validator rejects the candidate file. It holds the output of
+// the validator command.
+type ValidationError struct {
+	Path   string
+	Output string
+}
+
+func (e ValidationError) Error() string {
+	return fmt.Sprintf("Invalid configuration file %s: %s", e.Path, e.Output)
+}
+
+// NewValidator chains a writer that only writes the file once the validator command accepts it.
+// The candidate file is written to a staging file, next to the file and hidden from the dnsmasq
+// conf-dir, whose path replaces StagingFilePlaceholder in the command, e.g.
+// "dnsmasq --test --conf-file={file}". The file is rejected when the command exits with non-zero.
+func NewValidator(command string, next Writer) Writer {
+	return WriterFunc(func(path string, data []byte, mode os.FileMode) error {
+		if err := validate(command, path, data); err != nil {
+			return err
+		}
+
+		return next.WriteFile(path, data, mode)
+	})
+}
+
+func validate(command string, path string, data []byte) error {
+	dir, name := filepath.Split(path)
+	staging, err := os.CreateTemp(dir, "."+name+".*.staging")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(staging.Name())
+
+	_, err = staging.Write(data)
+	if closeErr := staging.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	command = strings.ReplaceAll(command, StagingFilePlaceholder, shellQuote(staging.Name()))
+	output, err := exec.CommandContext(ctx, "/bin/sh", "-c", command).CombinedOutput()
+	if err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return err
+		}
+
+		return ValidationError{
+			Path:   path,
+			Output: strings.TrimSpace(string(output)),
+		}
+	}
+
+	return nil
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
@@ -0,0 +1,135 @@
+package dnsmasq
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"golang.org/x/exp/slog"
+)
+
+// Watcher reports the files modified by any other tool than this service. The changes written
+// through its Writer are told apart by their content, so a file is only reported when it no longer
+// holds what was last written or seen.
+type Watcher struct {
+	mutex sync.Mutex
+	sums  map[string][sha256.Size]byte
+	fn    func(path string)
+}
+
+// NewWatcher starts watching the files, calling fn with the path of every file modified by another
+// tool. The directories of the files are watched instead of the files themselves, since the files
+// are usually replaced rather than rewritten.
+func NewWatcher(fn func(path string), paths ...string) (*Watcher, error) {
+	w := &Watcher{
+		sums: make(map[string][sha256.Size]byte, len(paths)),
+		fn:   fn,
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, path := range paths {
+		path = filepath.Clean(path)
+		sum, err := checksum(path)
+		if err != nil {
+			watcher.Close()
+			return nil, err
+		}
+		w.sums[path] = sum
+
+		if err := watcher.Add(filepath.Dir(path)); err != nil {
+			watcher.Close()
+			return nil, err
+		}
+	}
+
+	go w.watch(watcher)
+
+	return w, nil
+}
+
+// Writer records the content written to the watched files, so the change is not reported. The
+// files are not checked while they are written, as the events of their previous changes may still
+// be pending.
+func (w *Watcher) Writer(next Writer) Writer {
+	return WriterFunc(func(path string, data []byte, mode os.FileMode) error {
+		path = filepath.Clean(path)
+
+		w.mutex.Lock()
+		defer w.mutex.Unlock()
+
+		if err := next.WriteFile(path, data, mode); err != nil {
+			return err
+		}
+
+		if _, watched := w.sums[path]; watched {
+			w.sums[path] = sha256.Sum256(data)
+		}
+
+		return nil
+	})
+}
+
+func (w *Watcher) watch(watcher *fsnotify.Watcher) {
+	for {
+		select {
+		case event, ok := <-watcher.Events:
+			if !ok {
+				return
+			}
+			w.check(filepath.Clean(event.Name))
+		case err, ok := <-watcher.Errors:
+			if !ok {
+				return
+			}
+			slog.Error("Error watching the files", slog.String("error", err.Error()))
+		}
+	}
+}
+
+func (w *Watcher) check(path string) {
+	w.mutex.Lock()
+	known, watched := w.sums[path]
+	if !watched {
+		w.mutex.Unlock()
+		return
+	}
+
+	sum, err := checksum(path)
+	if err != nil {
+		w.mutex.Unlock()
+		slog.Error("Error reading the watched file",
+			slog.String("file", path),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	modified := sum != known
+	if modified {
+		w.sums[path] = sum
+	}
+	w.mutex.Unlock()
+
+	if modified {
+		slog.Info("File modified by another tool", slog.String("file", path))
+		w.fn(path)
+	}
+}
+
+// checksum returns the checksum of the content of the file, a missing file having no content.
+func checksum(path string) ([sha256.Size]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return [sha256.Size]byte{}, err
+	}
+
+	return sha256.Sum256(data), nil
+}
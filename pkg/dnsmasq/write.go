@@ -0,0 +1,85 @@
+package dnsmasq
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+)
+
+// WriteFile atomically replaces the file at path with data. The content is written and synced to
+// a temporary file in the same directory, which is then renamed over the original one, so readers
+// never see a partially written file. An existing file keeps its owner, group and mode, while a
+// new file is created with the given mode.
+func WriteFile(path string, data []byte, mode os.FileMode) error {
+	uid, gid := -1, -1
+	info, err := os.Stat(path)
+	if err == nil {
+		mode = info.Mode().Perm()
+		if stat, ok := info.Sys().(*syscall.Stat_t); ok {
+			uid, gid = int(stat.Uid), int(stat.Gid)
+		}
+	} else if !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	dir, name := filepath.Split(path)
+	temp, err := os.CreateTemp(dir, "."+name+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// No-op once the file was renamed into place
+		os.Remove(temp.Name())
+	}()
+
+	if err := writeTempFile(temp, data, mode, uid, gid); err != nil {
+		return err
+	}
+
+	if err := os.Rename(temp.Name(), path); err != nil {
+		return err
+	}
+
+	return syncDir(dir)
+}
+
+func writeTempFile(temp *os.File, data []byte, mode os.FileMode, uid int, gid int) error {
+	defer temp.Close()
+
+	if _, err := temp.Write(data); err != nil {
+		return err
+	}
+
+	if err := temp.Chmod(mode); err != nil {
+		return err
+	}
+
+	if uid >= 0 && gid >= 0 && (uid != os.Geteuid() || gid != os.Getegid()) {
+		if err := temp.Chown(uid, gid); err != nil {
+			return err
+		}
+	}
+
+	if err := temp.Sync(); err != nil {
+		return err
+	}
+
+	return temp.Close()
+}
+
+// syncDir persists the directory entry of a renamed file.
+func syncDir(dir string) error {
+	if dir == "" {
+		dir = "."
+	}
+
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -0,0 +1,20 @@
+package dnsmasq
+
+import (
+	"os"
+)
+
+// Writer writes the configuration files. Writers are chained to act before or after every change,
+// the last one of the chain actually writing the file.
+type Writer interface {
+	WriteFile(path string, data []byte, mode os.FileMode) error
+}
+
+type WriterFunc func(path string, data []byte, mode os.FileMode) error
+
+func (f WriterFunc) WriteFile(path string, data []byte, mode os.FileMode) error {
+	return f(path, data, mode)
+}
+
+// FileWriter atomically replaces the files, see WriteFile.
+var FileWriter Writer = WriterFunc(WriteFile)
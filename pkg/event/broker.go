@@ -0,0 +1,91 @@
+package event
+
+import (
+	"sync"
+)
+
+// subscriberBuffer is the number of events a subscriber can fall behind before being dropped
+const subscriberBuffer = 64
+
+// Broker publishes the events to its subscribers, keeping the most recent ones in a ring buffer so
+// a subscriber can resume from the last event it received.
+type Broker struct {
+	mutex       sync.Mutex
+	buffer      []*Event
+	next        int
+	full        bool
+	subscribers map[chan *Event]struct{}
+}
+
+// NewBroker creates a broker keeping up to size events.
+func NewBroker(size int) *Broker {
+	return &Broker{
+		buffer:      make([]*Event, size),
+		subscribers: map[chan *Event]struct{}{},
+	}
+}
+
+// Publish never blocks, a subscriber falling too far behind is dropped instead, its channel being
+// closed.
+func (b *Broker) Publish(event *Event) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if len(b.buffer) > 0 {
+		b.buffer[b.next] = event
+		b.next = (b.next + 1) % len(b.buffer)
+		b.full = b.full || b.next == 0
+	}
+
+	for ch := range b.subscribers {
+		select {
+		case ch <- event:
+		default:
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// Subscribe returns the channel receiving the events published from now on, along with the buffered
+// events published after the one with the last ID. All the buffered events are returned when the
+// last ID is not buffered, and none of them when it is empty. The cancel function must be called
+// once the subscriber is done.
+func (b *Broker) Subscribe(lastID string) (missed []*Event, events <-chan *Event, cancel func()) {
+	b.mutex.Lock()
+	defer b.mutex.Unlock()
+
+	if lastID != "" {
+		missed = b.since(lastID)
+	}
+
+	ch := make(chan *Event, subscriberBuffer)
+	b.subscribers[ch] = struct{}{}
+
+	return missed, ch, func() {
+		b.mutex.Lock()
+		defer b.mutex.Unlock()
+
+		if _, ok := b.subscribers[ch]; ok {
+			delete(b.subscribers, ch)
+			close(ch)
+		}
+	}
+}
+
+// since returns the buffered events, oldest first, published after the one with the ID.
+func (b *Broker) since(id string) []*Event {
+	buffered := []*Event{}
+	if b.full {
+		buffered = append(buffered, b.buffer[b.next:]...)
+	}
+	buffered = append(buffered, b.buffer[:b.next]...)
+
+	for i, event := range buffered {
+		if event.ID == id {
+			return buffered[i+1:]
+		}
+	}
+
+	return buffered
+}
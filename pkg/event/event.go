@@ -0,0 +1,72 @@
+package event
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"time"
+)
+
+// Type constants
+const (
+	HostCreated            = "host.created"
+	HostUpdated            = "host.updated"
+	HostDeleted            = "host.deleted"
+	LeaseCreated           = "lease.created"
+	LeaseUpdated           = "lease.updated"
+	LeaseDeleted           = "lease.deleted"
+	FileExternallyModified = "file.externally-modified"
+)
+
+// Actor identifies the user who made the change, from the claims of the JWT.
+type Actor struct {
+	Subject string `json:",omitempty"`
+	Name    string `json:",omitempty"`
+}
+
+// Event reports a change of the managed resources.
+type Event struct {
+	ID        string
+	Type      string
+	Time      time.Time
+	Actor     *Actor `json:",omitempty"`
+	RequestID string `json:",omitempty"`
+	// Before and After are the changed resource, Before is absent when it was created and After
+	// when it was deleted
+	Before json.RawMessage `json:",omitempty"`
+	After  json.RawMessage `json:",omitempty"`
+	// Scopes are the scopes allowing a user to receive the event, any of them
+	Scopes []string `json:"-"`
+}
+
+// Publisher delivers the events to their consumers. Publish must not block on the consumers.
+type Publisher interface {
+	Publish(event *Event)
+}
+
+// Publishers publishes the events to every publisher.
+type Publishers []Publisher
+
+func (p Publishers) Publish(event *Event) {
+	for _, publisher := range p {
+		publisher.Publish(event)
+	}
+}
+
+// New creates an event of the given type, with a new random ID.
+func New(eventType string) *Event {
+	return &Event{
+		ID:   newID(),
+		Type: eventType,
+		Time: time.Now().UTC(),
+	}
+}
+
+func newID() string {
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		panic(err)
+	}
+
+	return hex.EncodeToString(id)
+}
@@ -0,0 +1,340 @@
+package git
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/exp/slog"
+)
+
+// Committer identity of the commits, the author being the user who made the change
+const (
+	committerName  = "dnsmasq Manager"
+	committerEmail = "dnsmasq-manager@localhost"
+)
+
+// Committer is also the author of the changes made outside of dnsmasq Manager.
+var Committer = Signature{
+	Name:  committerName,
+	Email: committerEmail,
+}
+
+// fieldSeparator separates the fields of the log format
+const fieldSeparator = "\x1f"
+
+var (
+	ErrCommitNotFound = errors.New("commit not found")
+	ErrNotManaged     = errors.New("file outside of the git repository")
+)
+
+// Signature identifies the author of a commit.
+type Signature struct {
+	Name  string
+	Email string
+}
+
+func (s Signature) String() string {
+	return fmt.Sprintf("%s <%s>", s.Name, s.Email)
+}
+
+type Commit struct {
+	Hash    string
+	Author  Signature
+	Time    time.Time
+	Subject string
+	Body    string
+	// Files are the managed files changed by the commit, relative to the repository
+	Files []string
+}
+
+// ConflictError is returned when a commit cannot be reverted because the file was changed since.
+type ConflictError struct {
+	Path string
+}
+
+func (e ConflictError) Error() string {
+	return fmt.Sprintf("Conflicting changes in the file: %s", e.Path)
+}
+
+// Repository commits the changes of the managed files into the git repository holding them, using
+// the git command line.
+type Repository struct {
+	dir   string
+	files []string
+	mutex sync.Mutex
+}
+
+// Open opens the git repository at dir, creating it when there is none, to track the managed files
+// at paths, which must be within dir.
+func Open(dir string, paths ...string) (*Repository, error) {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &Repository{dir: dir}
+	for _, path := range paths {
+		file, err := r.relative(path)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", err, path)
+		}
+		r.files = append(r.files, file)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, ".git")); errors.Is(err, os.ErrNotExist) {
+		slog.Info("Creating the git repository", slog.String("dir", dir))
+		if _, err := r.git("init", "--quiet"); err != nil {
+			return nil, err
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	return r, nil
+}
+
+// relative returns the path of a managed file relative to the repository.
+func (r *Repository) relative(path string) (string, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+
+	file, err := filepath.Rel(r.dir, path)
+	if err != nil || file == ".." || strings.HasPrefix(file, "../") {
+		return "", ErrNotManaged
+	}
+
+	return file, nil
+}
+
+// Abs returns the absolute path of a file of the repository.
+func (r *Repository) Abs(file string) string {
+	return filepath.Join(r.dir, file)
+}
+
+// Commit commits the changes of the managed files, if any, and reports whether a commit was made.
+func (r *Repository) Commit(author Signature, message string) (bool, error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	files := r.existingFiles()
+	if len(files) == 0 {
+		return false, nil
+	}
+
+	if _, err := r.git(append([]string{"add", "--"}, files...)...); err != nil {
+		return false, err
+	}
+
+	// Exits with 1 when there are staged changes
+	if _, err := r.git(append([]string{"diff", "--cached", "--quiet", "--"}, files...)...); err == nil {
+		return false, nil
+	}
+
+	args := []string{"commit", "--quiet", "--author", author.String(), "--message", message, "--"}
+	if _, err := r.git(append(args, files...)...); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// existingFiles returns the managed files that exist or are tracked, which git can add.
+func (r *Repository) existingFiles() []string {
+	files := []string{}
+	for _, file := range r.files {
+		if _, err := os.Stat(r.Abs(file)); err == nil {
+			files = append(files, file)
+		} else if _, err := r.git("ls-files", "--error-unmatch", "--", file); err == nil {
+			files = append(files, file)
+		}
+	}
+
+	return files
+}
+
+// Log returns the last commits changing the managed files, the most recent first.
+func (r *Repository) Log(limit int) ([]Commit, error) {
+	args := []string{"log", "--format=" + logFormat, "--name-only", "-z"}
+	if limit > 0 {
+		args = append(args, "--max-count="+strconv.Itoa(limit))
+	}
+
+	output, err := r.git(append(append(args, "--"), r.files...)...)
+	if err != nil {
+		if r.isEmpty() {
+			return []Commit{}, nil
+		}
+		return nil, err
+	}
+
+	return parseLog(output), nil
+}
+
+// Show returns the commit along with its unified diff of the managed files.
+func (r *Repository) Show(hash string) (*Commit, string, error) {
+	if err := r.verify(hash); err != nil {
+		return nil, "", err
+	}
+
+	output, err := r.git(append([]string{"log", "--max-count=1", "--format=" + logFormat, "--name-only", "-z", hash, "--"}, r.files...)...)
+	if err != nil {
+		return nil, "", err
+	}
+	commits := parseLog(output)
+	if len(commits) == 0 {
+		// The commit exists, but does not change any managed file
+		return nil, "", ErrCommitNotFound
+	}
+
+	diff, err := r.git(append([]string{"show", "--format=", "--patch", hash, "--"}, r.files...)...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &commits[0], string(diff), nil
+}
+
+// Revert computes the content of every managed file changed by the commit, with the changes of the
+// commit undone on top of their current content, and passes it to restore.
+func (r *Repository) Revert(hash string, restore func(path string, data []byte) error) error {
+	commit, _, err := r.Show(hash)
+	if err != nil {
+		return err
+	}
+
+	for _, file := range commit.Files {
+		data, err := r.revertFile(commit.Hash, file)
+		if err != nil {
+			return err
+		}
+
+		if err := restore(r.Abs(file), data); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// revertFile merges the file as it was before the commit into its current content, using the
+// content of the commit as the common ancestor.
+func (r *Repository) revertFile(hash string, file string) ([]byte, error) {
+	temp, err := os.MkdirTemp("", "dnsmasq-manager-revert-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(temp)
+
+	current, err := os.ReadFile(r.Abs(file))
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	base, _ := r.git("show", hash+":"+file)
+	previous, _ := r.git("show", hash+"^:"+file)
+
+	paths := []string{}
+	for i, data := range [][]byte{current, base, previous} {
+		path := filepath.Join(temp, strconv.Itoa(i))
+		if err := os.WriteFile(path, data, 0600); err != nil {
+			return nil, err
+		}
+		paths = append(paths, path)
+	}
+
+	merged, err := r.git(append([]string{"merge-file", "--stdout"}, paths...)...)
+	if err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) && exitErr.ExitCode() > 0 {
+			return nil, ConflictError{Path: file}
+		}
+		return nil, err
+	}
+
+	return merged, nil
+}
+
+func (r *Repository) verify(hash string) error {
+	if strings.HasPrefix(hash, "-") {
+		return ErrCommitNotFound
+	}
+
+	if _, err := r.git("rev-parse", "--quiet", "--verify", hash+"^{commit}"); err != nil {
+		return ErrCommitNotFound
+	}
+
+	return nil
+}
+
+func (r *Repository) isEmpty() bool {
+	_, err := r.git("rev-parse", "--quiet", "--verify", "HEAD")
+	return err != nil
+}
+
+func (r *Repository) git(args ...string) ([]byte, error) {
+	cmd := exec.Command("git", args...)
+	cmd.Dir = r.dir
+	cmd.Env = append(os.Environ(),
+		"GIT_COMMITTER_NAME="+committerName,
+		"GIT_COMMITTER_EMAIL="+committerEmail,
+	)
+
+	stdout, stderr := bytes.Buffer{}, bytes.Buffer{}
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+	if err := cmd.Run(); err != nil {
+		slog.Debug("git command failed",
+			slog.Any("args", args),
+			slog.String("stderr", strings.TrimSpace(stderr.String())),
+		)
+		return stdout.Bytes(), fmt.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
+
+// logFormat prints the fields of each commit, followed by the changed files with --name-only
+var logFormat = strings.Join([]string{"%H", "%an", "%ae", "%aI", "%s", "%b"}, fieldSeparator) + fieldSeparator
+
+func parseLog(output []byte) []Commit {
+	// With -z, every field, including the changed files, ends with a NUL, and the header of each
+	// commit follows a NUL after the files of the previous one
+	commits := []Commit{}
+	for _, entry := range strings.Split(string(output), "\x00") {
+		entry = strings.TrimPrefix(entry, "\n")
+		if entry == "" {
+			continue
+		}
+
+		fields := strings.Split(entry, fieldSeparator)
+		if len(fields) < 7 {
+			// A file changed by the last commit
+			if len(commits) > 0 {
+				commits[len(commits)-1].Files = append(commits[len(commits)-1].Files, entry)
+			}
+			continue
+		}
+
+		commit := Commit{
+			Hash:    fields[0],
+			Author:  Signature{Name: fields[1], Email: fields[2]},
+			Subject: fields[4],
+			Body:    strings.TrimSpace(fields[5]),
+		}
+		commit.Time, _ = time.Parse(time.RFC3339, fields[3])
+		if file := strings.TrimPrefix(fields[6], "\n"); file != "" {
+			commit.Files = append(commit.Files, file)
+		}
+		commits = append(commits, commit)
+	}
+
+	return commits
+}
@@ -0,0 +1,95 @@
+package health
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"golang.org/x/sys/unix"
+)
+
+// Status of the checks
+const (
+	Pass = "pass"
+	Fail = "fail"
+)
+
+// Check reports whether a dependency of the service is ready, failing with the reason otherwise.
+type Check func() error
+
+// Result is the outcome of a check.
+type Result struct {
+	Name   string
+	Status string
+	Error  string
+}
+
+// Checker runs the readiness checks, in the order they were added.
+type Checker struct {
+	names  []string
+	checks []Check
+}
+
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+func (c *Checker) Add(name string, check Check) {
+	c.names = append(c.names, name)
+	c.checks = append(c.checks, check)
+}
+
+// Run runs every check, reporting whether all of them passed.
+func (c *Checker) Run() ([]Result, bool) {
+	results := make([]Result, 0, len(c.checks))
+	ready := true
+	for i, check := range c.checks {
+		result := Result{Name: c.names[i], Status: Pass}
+		if err := check(); err != nil {
+			result.Status = Fail
+			result.Error = err.Error()
+			ready = false
+		}
+		results = append(results, result)
+	}
+
+	return results, ready
+}
+
+// FileAccess checks that the file can be read and written. The files are written by replacing
+// them, so their directory must be writable as well, which is all a missing file needs to be
+// created.
+func FileAccess(path string) Check {
+	return func() error {
+		if err := unix.Access(path, unix.R_OK|unix.W_OK); err != nil && !errors.Is(err, unix.ENOENT) {
+			return &fs.PathError{Op: "access", Path: path, Err: err}
+		}
+
+		dir := filepath.Dir(path)
+		if err := unix.Access(dir, unix.W_OK); err != nil {
+			return &fs.PathError{Op: "access", Path: dir, Err: err}
+		}
+
+		return nil
+	}
+}
+
+// ProcessAlive checks that the process whose PID is in the pid file is running.
+func ProcessAlive(pidFile string) Check {
+	return func() error {
+		pid, err := dnsmasq.ReadPidFile(pidFile)
+		if err != nil {
+			return err
+		}
+
+		// The signal 0 only checks the process, which is alive when it is not ours to signal
+		err = unix.Kill(pid, 0)
+		if err != nil && !errors.Is(err, unix.EPERM) {
+			return fmt.Errorf("process %d: %w", pid, err)
+		}
+
+		return nil
+	}
+}
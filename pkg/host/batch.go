@@ -0,0 +1,97 @@
+package host
+
+import (
+	"errors"
+	"net"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+type Action string
+
+const (
+	ActionAdd    Action = "add"
+	ActionUpdate Action = "update"
+	ActionRemove Action = "remove"
+)
+
+// Operation is a single change of a batch. Updates and removals target the host holding either
+// the MAC address, the IP address or the host name.
+type Operation struct {
+	Action     Action
+	MacAddress model.HardwareAddress
+	IPAddress  net.IP
+	HostName   string
+	Host       *model.StaticDhcpHost
+	Options    Options
+}
+
+// Result is the outcome of an Operation. Previous holds the host replaced or removed by it.
+type Result struct {
+	Previous *model.StaticDhcpHost
+	Err      error
+}
+
+var (
+	ErrInvalidOperation = errors.New("invalid batch operation")
+	ErrNotApplied       = errors.New("operation not applied because another operation of the batch failed")
+)
+
+// Batch applies all the operations, in order, as a single transaction: either all of them are
+// written at once, or none is. Besides the result of each operation, the error of the first
+// failing one is returned.
+func (s *service) Batch(operations []Operation) ([]Result, error) {
+	results := make([]Result, len(operations))
+	err := s.repository.Transaction(func(repository Repository) error {
+		for i, operation := range operations {
+			results[i].Previous, results[i].Err = apply(repository, operation)
+			if results[i].Err != nil {
+				for j := i + 1; j < len(operations); j++ {
+					results[j].Err = ErrNotApplied
+				}
+				return results[i].Err
+			}
+		}
+
+		return nil
+	})
+
+	return results, err
+}
+
+func apply(repository Repository, operation Operation) (*model.StaticDhcpHost, error) {
+	if operation.Action == ActionAdd {
+		if operation.Host == nil {
+			return nil, ErrInvalidOperation
+		}
+		return nil, insert(repository, operation.Host)
+	}
+
+	var current *model.StaticDhcpHost
+	var err error
+	switch {
+	case operation.MacAddress != "":
+		current, err = repository.FindByMac(operation.MacAddress)
+	case operation.IPAddress != nil:
+		current, err = repository.FindByIP(operation.IPAddress)
+	case operation.HostName != "":
+		current, err = repository.FindByHostName(operation.HostName)
+	default:
+		return nil, ErrInvalidOperation
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch operation.Action {
+	case ActionUpdate:
+		if operation.Host == nil {
+			return nil, ErrInvalidOperation
+		}
+		return current, update(repository, current, operation.Host, operation.Options)
+	case ActionRemove:
+		return current, remove(repository, current, operation.Options)
+	default:
+		return nil, ErrInvalidOperation
+	}
+}
@@ -0,0 +1,260 @@
+package host
+
+import (
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+// cachedRepository keeps the static hosts in memory, indexed by MAC address, IP address and host
+// name. The cache is dropped on every change made through it, and whenever the static hosts file
+// is modified by anyone else.
+type cachedRepository struct {
+	repository Repository
+	mutex      sync.RWMutex
+	index      *index
+	generation uint64
+}
+
+func NewCachedRepository(repository Repository, staticHostsFilePath string) (Repository, error) {
+	c := &cachedRepository{
+		repository: repository,
+	}
+
+	if err := c.watch(staticHostsFilePath); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+func (c *cachedRepository) FindAll() (*[]model.StaticDhcpHost, error) {
+	idx, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	hosts := slices.Clone(idx.hosts)
+	return &hosts, nil
+}
+
+func (c *cachedRepository) Find(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error) {
+	idx, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	i := slices.IndexFunc(idx.hosts, sameHost(host))
+	return idx.get(i), nil
+}
+
+func (c *cachedRepository) FindByMac(macAddress model.HardwareAddress) (*model.StaticDhcpHost, error) {
+	idx, err := c.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return idx.lookup(idx.byMac, string(macAddress)), nil
+}
+
+func (c *cachedRepository) FindByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
+	idx, err := c.load()
+	if err != nil || ipAddress == nil {
+		return nil, err
+	}
+
+	if ipAddress.To4() == nil {
+		// IPv6 reservations may be ranges or host identifiers only, which can not be indexed
+		i := slices.IndexFunc(idx.hosts, sameIPAddress(ipAddress))
+		return idx.get(i), nil
+	}
+
+	return idx.lookup(idx.byIP, ipAddress.String()), nil
+}
+
+func (c *cachedRepository) FindByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	idx, err := c.load()
+	if err != nil || hostName == "" {
+		return nil, err
+	}
+
+	return idx.lookup(idx.byHostName, strings.ToLower(hostName)), nil
+}
+
+func (c *cachedRepository) Save(host *model.StaticDhcpHost) error {
+	defer c.invalidate()
+	return c.repository.Save(host)
+}
+
+func (c *cachedRepository) Update(current *model.StaticDhcpHost, host *model.StaticDhcpHost) error {
+	defer c.invalidate()
+	return c.repository.Update(current, host)
+}
+
+func (c *cachedRepository) Delete(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error) {
+	defer c.invalidate()
+	return c.repository.Delete(host)
+}
+
+func (c *cachedRepository) DeleteByMac(macAddress model.HardwareAddress) (*model.StaticDhcpHost, error) {
+	defer c.invalidate()
+	return c.repository.DeleteByMac(macAddress)
+}
+
+func (c *cachedRepository) DeleteByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
+	defer c.invalidate()
+	return c.repository.DeleteByIP(ipAddress)
+}
+
+func (c *cachedRepository) DeleteByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	defer c.invalidate()
+	return c.repository.DeleteByHostName(hostName)
+}
+
+func (c *cachedRepository) Restore(data []byte) error {
+	defer c.invalidate()
+	return c.repository.Restore(data)
+}
+
+func (c *cachedRepository) Transaction(fn func(Repository) error) error {
+	defer c.invalidate()
+	return c.repository.Transaction(fn)
+}
+
+// DryRun leaves the cache untouched, as nothing is written.
+func (c *cachedRepository) DryRun(fn func(Repository) error) (string, error) {
+	return c.repository.DryRun(fn)
+}
+
+func (c *cachedRepository) load() (*index, error) {
+	c.mutex.RLock()
+	idx, generation := c.index, c.generation
+	c.mutex.RUnlock()
+
+	if idx != nil {
+		return idx, nil
+	}
+
+	hosts, err := c.repository.FindAll()
+	if err != nil {
+		return nil, err
+	}
+
+	idx = newIndex(*hosts)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	// Only cache the hosts if the file was not modified while they were being loaded
+	if c.generation == generation {
+		c.index = idx
+	}
+
+	return idx, nil
+}
+
+func (c *cachedRepository) invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.index = nil
+	c.generation++
+}
+
+// watch invalidates the cache on changes to the static hosts file. The directory is watched
+// instead of the file itself, since the file is replaced rather than rewritten on every change.
+func (c *cachedRepository) watch(staticHostsFilePath string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Clean(staticHostsFilePath)
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) == file {
+					slog.Debug("Static hosts file changed, invalidating cache",
+						slog.String("file", file),
+						slog.String("event", event.Op.String()),
+					)
+					c.invalidate()
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Error("Error watching the static hosts file",
+					slog.String("file", file),
+					slog.String("error", err.Error()),
+				)
+				c.invalidate()
+			}
+		}
+	}()
+
+	return nil
+}
+
+type index struct {
+	hosts      []model.StaticDhcpHost
+	byMac      map[string]int
+	byIP       map[string]int
+	byHostName map[string]int
+}
+
+func newIndex(hosts []model.StaticDhcpHost) *index {
+	idx := &index{
+		hosts:      hosts,
+		byMac:      make(map[string]int, len(hosts)),
+		byIP:       make(map[string]int, len(hosts)),
+		byHostName: make(map[string]int, len(hosts)),
+	}
+
+	// Lookups return the first matching entry, as the repository does
+	for i := len(hosts) - 1; i >= 0; i-- {
+		for _, mac := range hosts[i].MacAddresses {
+			idx.byMac[string(mac)] = i
+		}
+		if hosts[i].IPAddress != nil {
+			idx.byIP[hosts[i].IPAddress.String()] = i
+		}
+		if hosts[i].HostName != "" {
+			idx.byHostName[strings.ToLower(hosts[i].HostName)] = i
+		}
+	}
+
+	return idx
+}
+
+func (idx *index) lookup(keys map[string]int, key string) *model.StaticDhcpHost {
+	i, found := keys[key]
+	if !found {
+		return nil
+	}
+
+	return idx.get(i)
+}
+
+func (idx *index) get(i int) *model.StaticDhcpHost {
+	if i < 0 {
+		return nil
+	}
+
+	host := idx.hosts[i]
+	return &host
+}
@@ -0,0 +1,62 @@
+package host
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"golang.org/x/exp/slices"
+)
+
+// Change is a static host added, replaced or removed by a mutation. Before is nil when the host
+// was added, and After is nil when it was removed.
+type Change struct {
+	Before *model.StaticDhcpHost
+	After  *model.StaticDhcpHost
+}
+
+// Changes returns the changes turning the before hosts into the after ones. A removed host and an
+// added one sharing any of their MAC addresses, IP addresses or host name are reported as a single
+// replaced host.
+func Changes(before []model.StaticDhcpHost, after []model.StaticDhcpHost) []Change {
+	removed := slices.Clone(before)
+	added := []model.StaticDhcpHost{}
+	for _, host := range after {
+		if i := slices.IndexFunc(removed, sameHost(&host)); i >= 0 {
+			removed = slices.Delete(removed, i, i+1)
+			continue
+		}
+		added = append(added, host)
+	}
+
+	changes := []Change{}
+	for i := range added {
+		change := Change{After: &added[i]}
+		j := slices.IndexFunc(removed, func(other model.StaticDhcpHost) bool {
+			return newDuplicatedEntryError(change.After, &other) != nil
+		})
+		if j >= 0 {
+			host := removed[j]
+			change.Before = &host
+			removed = slices.Delete(removed, j, j+1)
+		}
+		changes = append(changes, change)
+	}
+	for i := range removed {
+		changes = append(changes, Change{Before: &removed[i]})
+	}
+
+	return changes
+}
+
+// FileChanges returns the changes between two versions of the static hosts file.
+func FileChanges(before []byte, after []byte) ([]Change, error) {
+	beforeDoc, err := parse(before)
+	if err != nil {
+		return nil, err
+	}
+
+	afterDoc, err := parse(after)
+	if err != nil {
+		return nil, err
+	}
+
+	return Changes(beforeDoc.doc.Entries(), afterDoc.doc.Entries()), nil
+}
@@ -0,0 +1,32 @@
+package host
+
+import (
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/query"
+)
+
+// Record returns the queryable fields of a static host.
+func Record(host model.StaticDhcpHost) query.Record {
+	record := query.Record{
+		HostName: host.HostName,
+		Key:      host.ToConfig(),
+	}
+
+	for _, mac := range host.MacAddresses {
+		record.MacAddresses = append(record.MacAddresses, mac.String())
+	}
+
+	if host.IPAddress != nil {
+		record.IPAddresses = append(record.IPAddresses, host.IPAddress)
+	}
+	for _, address := range host.IPv6Addresses {
+		record.IPAddresses = append(record.IPAddresses, address.IP)
+	}
+
+	return record
+}
+
+// Query returns the page of the static hosts selected by the query.
+func Query(hosts []model.StaticDhcpHost, q query.Query) (*query.Page[model.StaticDhcpHost], error) {
+	return query.Apply(hosts, Record, q)
+}
@@ -1,168 +1,324 @@
 package host
 
 import (
-	"bufio"
-	"bytes"
+	"errors"
+	"io/fs"
 	"net"
 	"os"
 	"strings"
 
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
 	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"golang.org/x/exp/slices"
 	"golang.org/x/exp/slog"
 )
 
 type Repository interface {
 	Delete(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error)
-	DeleteByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error)
+	DeleteByMac(macAddress model.HardwareAddress) (*model.StaticDhcpHost, error)
 	DeleteByIP(ipAddress net.IP) (*model.StaticDhcpHost, error)
+	DeleteByHostName(hostName string) (*model.StaticDhcpHost, error)
 	Find(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error)
 	FindAll() (*[]model.StaticDhcpHost, error)
-	FindByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error)
+	FindByMac(macAddress model.HardwareAddress) (*model.StaticDhcpHost, error)
 	FindByIP(ipAddress net.IP) (*model.StaticDhcpHost, error)
+	FindByHostName(hostName string) (*model.StaticDhcpHost, error)
 	Save(host *model.StaticDhcpHost) error
+	Update(current *model.StaticDhcpHost, host *model.StaticDhcpHost) error
+	// Restore replaces the whole static hosts file with data, which must only hold valid entries.
+	Restore(data []byte) error
+	// Transaction runs fn as a single critical section over the static hosts. All the changes
+	// made through the given Repository are written at once when fn succeeds, and discarded
+	// otherwise.
+	Transaction(fn func(Repository) error) error
+	// DryRun runs fn as Transaction does, but always discards the changes, returning the unified
+	// diff of the static hosts file they would have made.
+	DryRun(fn func(Repository) error) (string, error)
 }
 
+var (
+	ErrHostNotFound = errors.New("static DHCP host not found")
+	ErrNestedDryRun = errors.New("dry run within a transaction")
+)
+
+const dhcpHostDirective = "dhcp-host"
+
 type repository struct {
-	staticHostsFilePath string
+	store *dnsmasq.Store[model.StaticDhcpHost]
+	inUse dnsmasq.NamesInUse
 }
 
-func NewRepository(staticHostsFilePath string) Repository {
+// NewRepository creates the repository of the file, writing the changes through the writer. The
+// changes removing or renaming any host name still in use, as told by inUse, are rejected.
+func NewRepository(staticHostsFilePath string, fileMode os.FileMode, writer dnsmasq.Writer, inUse dnsmasq.NamesInUse) Repository {
 	return &repository{
-		staticHostsFilePath: staticHostsFilePath,
+		store: dnsmasq.NewStore("static hosts", staticHostsFilePath, fileMode, writer, format),
+		inUse: inUse,
 	}
 }
 
+var format = dnsmasq.Format[model.StaticDhcpHost]{
+	Parse:  parseLine,
+	Config: (*model.StaticDhcpHost).ToConfig,
+}
+
 func (r *repository) FindAll() (*[]model.StaticDhcpHost, error) {
-	return r.load()
+	doc, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.FindAll()
 }
 
 func (r *repository) Find(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error) {
-	return r.find(sameHost(host))
+	doc, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.Find(host)
 }
 
-func (r *repository) FindByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
-	return r.find(sameMacAddress(macAddress))
+func (r *repository) FindByMac(macAddress model.HardwareAddress) (*model.StaticDhcpHost, error) {
+	doc, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.FindByMac(macAddress)
 }
 
 func (r *repository) FindByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
-	return r.find(sameIPAddress(ipAddress))
+	doc, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return doc.FindByIP(ipAddress)
 }
 
-func (r *repository) Save(host *model.StaticDhcpHost) error {
-	hosts, err := r.load()
+func (r *repository) FindByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	doc, err := r.load()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	*hosts = append(*hosts, *host)
-	return r.save(hosts)
+	return doc.FindByHostName(hostName)
 }
 
-func (r *repository) Delete(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error) {
-	return r.delete(sameHost(host))
+func (r *repository) Save(host *model.StaticDhcpHost) error {
+	return r.Transaction(func(tx Repository) error {
+		return tx.Save(host)
+	})
 }
 
-func (r *repository) DeleteByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
-	return r.delete(sameMacAddress(macAddress))
+func (r *repository) Update(current *model.StaticDhcpHost, host *model.StaticDhcpHost) error {
+	return r.Transaction(func(tx Repository) error {
+		return tx.Update(current, host)
+	})
 }
 
-func (r *repository) DeleteByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
-	return r.delete(sameIPAddress(ipAddress))
+func (r *repository) Delete(host *model.StaticDhcpHost) (deleted *model.StaticDhcpHost, err error) {
+	err = r.Transaction(func(tx Repository) error {
+		deleted, err = tx.Delete(host)
+		return err
+	})
+	return deleted, err
 }
 
-func (r *repository) load() (*[]model.StaticDhcpHost, error) {
-	file, err := os.Open(r.staticHostsFilePath)
-	if err != nil {
-		slog.Error("Error reading static hosts file",
-			slog.String("file", r.staticHostsFilePath),
-			slog.String("error", err.Error()),
-		)
-		return nil, err
-	}
-	defer file.Close()
+func (r *repository) DeleteByMac(macAddress model.HardwareAddress) (deleted *model.StaticDhcpHost, err error) {
+	err = r.Transaction(func(tx Repository) error {
+		deleted, err = tx.DeleteByMac(macAddress)
+		return err
+	})
+	return deleted, err
+}
+
+func (r *repository) DeleteByIP(ipAddress net.IP) (deleted *model.StaticDhcpHost, err error) {
+	err = r.Transaction(func(tx Repository) error {
+		deleted, err = tx.DeleteByIP(ipAddress)
+		return err
+	})
+	return deleted, err
+}
 
-	return r.parse(file)
+func (r *repository) DeleteByHostName(hostName string) (deleted *model.StaticDhcpHost, err error) {
+	err = r.Transaction(func(tx Repository) error {
+		deleted, err = tx.DeleteByHostName(hostName)
+		return err
+	})
+	return deleted, err
 }
 
-func (r *repository) parse(file *os.File) (*[]model.StaticDhcpHost, error) {
-	scanner := bufio.NewScanner(file)
-	scanner.Split(bufio.ScanLines)
+func (r *repository) Restore(data []byte) error {
+	return r.Transaction(func(tx Repository) error {
+		return tx.Restore(data)
+	})
+}
 
-	hosts := []model.StaticDhcpHost{}
-	for scanner.Scan() {
-		if !strings.HasPrefix(scanner.Text(), "dhcp-host=") {
-			slog.Debug("Skipping line", slog.String("line", scanner.Text()))
-			continue
-		}
-		slog.Debug("Parsing line", slog.String("line", scanner.Text()))
-
-		host := model.StaticDhcpHost{}
-		err := host.FromConfig(scanner.Text())
-		if err != nil {
-			slog.Error("Failed to parse static DHCP host entry",
-				slog.String("entry", scanner.Text()),
-				slog.String("error", err.Error()),
-			)
-			return nil, err
-		}
+func (r *repository) Transaction(fn func(Repository) error) error {
+	return r.store.Transaction(func(doc *dnsmasq.Document[model.StaticDhcpHost]) error {
+		return r.run(doc, fn)
+	})
+}
+
+func (r *repository) DryRun(fn func(Repository) error) (string, error) {
+	return r.store.DryRun(func(doc *dnsmasq.Document[model.StaticDhcpHost]) error {
+		return r.run(doc, fn)
+	})
+}
 
-		hosts = append(hosts, host)
+// run runs fn over the document, failing when it removed or renamed any host name still in use.
+func (r *repository) run(doc *dnsmasq.Document[model.StaticDhcpHost], fn func(Repository) error) error {
+	before := hostNames(doc.Entries())
+	if err := fn(&document{doc: doc}); err != nil {
+		return err
 	}
 
-	return &hosts, nil
+	if r.inUse == nil || !doc.Changed() {
+		return nil
+	}
+
+	removed := dnsmasq.RemovedNames(before, hostNames(doc.Entries()))
+	if len(removed) == 0 {
+		return nil
+	}
+
+	return r.inUse(removed)
 }
 
-func (r *repository) save(hosts *[]model.StaticDhcpHost) error {
-	config := make([]string, 0, len(*hosts))
-	for _, host := range *hosts {
-		config = append(config, host.ToConfig())
+func hostNames(hosts []model.StaticDhcpHost) []string {
+	names := make([]string, 0, len(hosts))
+	for _, h := range hosts {
+		if h.HostName != "" {
+			names = append(names, h.HostName)
+		}
 	}
 
-	err := os.WriteFile(r.staticHostsFilePath, []byte(strings.Join(config, "\n")), os.FileMode(0644))
+	return names
+}
+
+func (r *repository) load() (*document, error) {
+	doc, err := r.store.Load()
 	if err != nil {
-		slog.Error("Error writing into the static hosts file",
-			slog.String("file", r.staticHostsFilePath),
-			slog.String("error", err.Error()),
-		)
-		return err
+		return nil, err
 	}
 
-	return nil
+	return &document{doc: doc}, nil
 }
 
-func (r *repository) delete(filter Filter) (*model.StaticDhcpHost, error) {
-	hosts, err := r.load()
+// CheckFile reads and parses the static hosts file, failing when it cannot be read or holds an
+// invalid entry. A missing file holds no entries.
+func CheckFile(staticHostsFilePath string) error {
+	data, err := os.ReadFile(staticHostsFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	h := *hosts
-	for i, host := range h {
-		if !filter(host) {
-			continue
-		}
+	_, err = parse(data)
+	return err
+}
 
-		*hosts = append(h[:i], h[i+1:]...)
-		err := r.save(hosts)
-		return &host, err
+func parse(data []byte) (*document, error) {
+	doc, err := dnsmasq.ParseDocument(data, format)
+	if err != nil {
+		return nil, err
 	}
 
-	return nil, nil
+	return &document{doc: doc}, nil
 }
 
-func (r *repository) find(filter Filter) (*model.StaticDhcpHost, error) {
-	hosts, err := r.load()
+func parseLine(line string) (*model.StaticDhcpHost, error) {
+	if name, _, ok := dnsmasq.Directive(line); !ok || name != dhcpHostDirective {
+		slog.Debug("Skipping line", slog.String("line", line))
+		return nil, nil
+	}
+	slog.Debug("Parsing line", slog.String("line", line))
+
+	host := model.StaticDhcpHost{}
+	err := host.FromConfig(line)
 	if err != nil {
+		slog.Error("Failed to parse static DHCP host entry",
+			slog.String("entry", line),
+			slog.String("error", err.Error()),
+		)
 		return nil, err
 	}
 
-	for _, host := range *hosts {
-		if filter(host) {
-			return &host, nil
-		}
+	return &host, nil
+}
+
+// document implements the Repository operations in memory over the parsed static hosts file, to
+// be used within a transaction.
+type document struct {
+	doc *dnsmasq.Document[model.StaticDhcpHost]
+}
+
+func (d *document) FindAll() (*[]model.StaticDhcpHost, error) {
+	hosts := d.doc.Entries()
+	return &hosts, nil
+}
+
+func (d *document) Find(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error) {
+	return d.doc.Find(sameHost(host)), nil
+}
+
+func (d *document) FindByMac(macAddress model.HardwareAddress) (*model.StaticDhcpHost, error) {
+	return d.doc.Find(sameMacAddress(macAddress)), nil
+}
+
+func (d *document) FindByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
+	return d.doc.Find(sameIPAddress(ipAddress)), nil
+}
+
+func (d *document) FindByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	return d.doc.Find(sameHostName(hostName)), nil
+}
+
+func (d *document) Save(host *model.StaticDhcpHost) error {
+	d.doc.Insert(host)
+	return nil
+}
+
+func (d *document) Update(current *model.StaticDhcpHost, host *model.StaticDhcpHost) error {
+	if !d.doc.Update(sameHost(current), host) {
+		return ErrHostNotFound
 	}
 
-	return nil, nil
+	return nil
+}
+
+func (d *document) Delete(host *model.StaticDhcpHost) (*model.StaticDhcpHost, error) {
+	return d.doc.Delete(sameHost(host)), nil
+}
+
+func (d *document) DeleteByMac(macAddress model.HardwareAddress) (*model.StaticDhcpHost, error) {
+	return d.doc.Delete(sameMacAddress(macAddress)), nil
+}
+
+func (d *document) DeleteByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
+	return d.doc.Delete(sameIPAddress(ipAddress)), nil
+}
+
+func (d *document) DeleteByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	return d.doc.Delete(sameHostName(hostName)), nil
+}
+
+func (d *document) Restore(data []byte) error {
+	return d.doc.Restore(data)
+}
+
+// Transaction runs fn straight away, the document is already part of one.
+func (d *document) Transaction(fn func(Repository) error) error {
+	return fn(d)
+}
+
+func (d *document) DryRun(fn func(Repository) error) (string, error) {
+	return "", ErrNestedDryRun
 }
 
 type Filter func(model.StaticDhcpHost) bool
@@ -172,14 +328,27 @@ func sameHost(host *model.StaticDhcpHost) Filter {
 		return host.Equal(other)
 	}
 }
-func sameMacAddress(macAddress net.HardwareAddr) Filter {
+
+func sameMacAddress(macAddress model.HardwareAddress) Filter {
 	return func(other model.StaticDhcpHost) bool {
-		return bytes.Equal(macAddress, other.MacAddress)
+		return slices.Contains(other.MacAddresses, macAddress)
 	}
 }
 
 func sameIPAddress(ipAddress net.IP) Filter {
 	return func(other model.StaticDhcpHost) bool {
-		return bytes.Equal(ipAddress, other.IPAddress)
+		return other.HasIPAddress(ipAddress)
+	}
+}
+
+func sameIPv6Address(address model.IPv6Address) Filter {
+	return func(other model.StaticDhcpHost) bool {
+		return other.HasIPv6Address(address)
+	}
+}
+
+func sameHostName(hostName string) Filter {
+	return func(other model.StaticDhcpHost) bool {
+		return hostName != "" && strings.EqualFold(hostName, other.HostName)
 	}
 }
@@ -1,6 +1,7 @@
 package host
 
 import (
+	"errors"
 	"fmt"
 	"net"
 
@@ -9,13 +10,50 @@ import (
 
 type Service interface {
 	Insert(host *model.StaticDhcpHost) error
-	Update(host *model.StaticDhcpHost) error
+	UpdateByIP(ipAddress net.IP, host *model.StaticDhcpHost, options Options) (*model.StaticDhcpHost, error)
+	UpdateByMac(macAddress model.HardwareAddress, host *model.StaticDhcpHost, options Options) (*model.StaticDhcpHost, error)
+	UpdateByHostName(hostName string, host *model.StaticDhcpHost, options Options) (*model.StaticDhcpHost, error)
 	FetchAll() (*[]model.StaticDhcpHost, error)
 	FetchByIP(ipAddress net.IP) (*model.StaticDhcpHost, error)
-	FetchByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error)
-	RemoveByIP(ipAddress net.IP) (*model.StaticDhcpHost, error)
-	RemoveByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error)
+	FetchByMac(macAddress model.HardwareAddress) (*model.StaticDhcpHost, error)
+	FetchByHostName(hostName string) (*model.StaticDhcpHost, error)
+	RemoveByIP(ipAddress net.IP, options Options) (*model.StaticDhcpHost, error)
+	RemoveByMac(macAddress model.HardwareAddress, options Options) (*model.StaticDhcpHost, error)
+	RemoveByHostName(hostName string, options Options) (*model.StaticDhcpHost, error)
+	Batch(operations []Operation) ([]Result, error)
+	// DryRun runs fn against a service whose changes are checked as usual, but never written. It
+	// returns the unified diff of the static hosts file the changes would have made.
+	DryRun(fn func(Service) error) (string, error)
+	// Restore replaces all the static hosts with the ones of a previous version of the static hosts
+	// file.
+	Restore(data []byte) error
+	// Observe runs fn as a single transaction against a service whose changes are written as
+	// usual. It returns the static hosts changed by fn once they are written.
+	Observe(fn func(Service) error) ([]Change, error)
 }
+
+// Options tweak how a change to an existing host is applied.
+type Options struct {
+	// Force removes any other host conflicting with the updated one.
+	Force bool
+	// Precondition, when set, must accept the current host for the change to be applied.
+	Precondition Precondition
+}
+
+// Precondition checks the current host, or nil if there is none, within the same transaction that
+// changes it. It should return ErrPreconditionFailed to reject the change.
+type Precondition func(current *model.StaticDhcpHost) error
+
+var ErrPreconditionFailed = errors.New("precondition failed")
+
+func (o Options) check(current *model.StaticDhcpHost) error {
+	if o.Precondition == nil {
+		return nil
+	}
+
+	return o.Precondition(current)
+}
+
 type service struct {
 	repository Repository
 }
@@ -26,45 +64,159 @@ func NewService(repository Repository) Service {
 	}
 }
 
+func (s *service) DryRun(fn func(Service) error) (string, error) {
+	return s.repository.DryRun(func(repository Repository) error {
+		return fn(&service{repository: repository})
+	})
+}
+
+func (s *service) Observe(fn func(Service) error) (changes []Change, err error) {
+	err = s.repository.Transaction(func(repository Repository) error {
+		before, err := repository.FindAll()
+		if err != nil {
+			return err
+		}
+
+		if err := fn(&service{repository: repository}); err != nil {
+			return err
+		}
+
+		after, err := repository.FindAll()
+		if err != nil {
+			return err
+		}
+
+		changes = Changes(*before, *after)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return changes, nil
+}
+
+func (s *service) Restore(data []byte) error {
+	return s.repository.Restore(data)
+}
+
 func (s *service) Insert(host *model.StaticDhcpHost) error {
-	sameMacHost, err := s.repository.FindByMac(host.MacAddress)
+	return s.repository.Transaction(func(repository Repository) error {
+		return insert(repository, host)
+	})
+}
+
+// UpdateByMac replaces the host holding the given MAC address, or adds a new one when there is none.
+// It fails if any other host already uses one of the new MAC addresses, the IP address or the host
+// name, unless forced, in which case those hosts are removed. The replaced host is returned.
+func (s *service) UpdateByMac(macAddress model.HardwareAddress, host *model.StaticDhcpHost, options Options) (current *model.StaticDhcpHost, err error) {
+	err = s.repository.Transaction(func(repository Repository) error {
+		current, err = repository.FindByMac(macAddress)
+		if err != nil {
+			return err
+		}
+
+		return update(repository, current, host, options)
+	})
+	return current, err
+}
+
+// UpdateByIP works as UpdateByMac, but looks up the host to be replaced by its IP address.
+func (s *service) UpdateByIP(ipAddress net.IP, host *model.StaticDhcpHost, options Options) (current *model.StaticDhcpHost, err error) {
+	err = s.repository.Transaction(func(repository Repository) error {
+		current, err = repository.FindByIP(ipAddress)
+		if err != nil {
+			return err
+		}
+
+		return update(repository, current, host, options)
+	})
+	return current, err
+}
+
+// UpdateByHostName works as UpdateByMac, but looks up the host to be replaced by its host name.
+func (s *service) UpdateByHostName(hostName string, host *model.StaticDhcpHost, options Options) (current *model.StaticDhcpHost, err error) {
+	err = s.repository.Transaction(func(repository Repository) error {
+		current, err = repository.FindByHostName(hostName)
+		if err != nil {
+			return err
+		}
+
+		return update(repository, current, host, options)
+	})
+	return current, err
+}
+
+// insert adds the host, failing if any other host already uses one of its MAC addresses, its IP
+// addresses or its host name, checked as update does.
+func insert(repository Repository, host *model.StaticDhcpHost) error {
+	conflicts, err := findConflicts(repository, nil, host)
 	if err != nil {
 		return err
 	}
-	if sameMacHost != nil {
-		return &DuplicatedEntryError{Field: "MAC", Value: host.MacAddress.String()}
+	if len(conflicts) > 0 {
+		return newDuplicatedEntryError(host, &conflicts[0])
+	}
+
+	return repository.Save(host)
+}
+
+func update(repository Repository, current *model.StaticDhcpHost, host *model.StaticDhcpHost, options Options) error {
+	if err := options.check(current); err != nil {
+		return err
 	}
 
-	sameIPHost, err := s.repository.FindByIP(host.IPAddress)
+	conflicts, err := findConflicts(repository, current, host)
 	if err != nil {
 		return err
 	}
-	if sameIPHost != nil {
-		return &DuplicatedEntryError{Field: "IP", Value: host.IPAddress.String()}
+
+	for _, other := range conflicts {
+		if !options.Force {
+			return newDuplicatedEntryError(host, &other)
+		}
+
+		if _, err := repository.Delete(&other); err != nil {
+			return err
+		}
 	}
 
-	return s.repository.Save(host)
+	if current == nil {
+		return repository.Save(host)
+	}
+
+	return repository.Update(current, host)
 }
 
-func (s *service) Update(host *model.StaticDhcpHost) error {
-	_, err := s.repository.DeleteByMac(host.MacAddress)
+// findConflicts returns every host, other than the current one, that shares any of the MAC
+// addresses, the IP addresses or the host name with the given host.
+func findConflicts(repository Repository, current *model.StaticDhcpHost, host *model.StaticDhcpHost) ([]model.StaticDhcpHost, error) {
+	hosts, err := repository.FindAll()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	_, err = s.repository.DeleteByIP(host.IPAddress)
-	if err != nil {
-		return err
+	skipped := current == nil
+	conflicts := []model.StaticDhcpHost{}
+	for _, other := range *hosts {
+		if !skipped && current.Equal(other) {
+			skipped = true
+			continue
+		}
+
+		if newDuplicatedEntryError(host, &other) != nil {
+			conflicts = append(conflicts, other)
+		}
 	}
 
-	return s.repository.Save(host)
+	return conflicts, nil
 }
 
 func (s *service) FetchAll() (*[]model.StaticDhcpHost, error) {
 	return s.repository.FindAll()
 }
 
-func (s *service) FetchByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
+func (s *service) FetchByMac(macAddress model.HardwareAddress) (*model.StaticDhcpHost, error) {
 	return s.repository.FindByMac(macAddress)
 }
 
@@ -72,12 +224,82 @@ func (s *service) FetchByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
 	return s.repository.FindByIP(ipAddress)
 }
 
-func (s *service) RemoveByMac(macAddress net.HardwareAddr) (*model.StaticDhcpHost, error) {
-	return s.repository.DeleteByMac(macAddress)
+func (s *service) FetchByHostName(hostName string) (*model.StaticDhcpHost, error) {
+	return s.repository.FindByHostName(hostName)
 }
 
-func (s *service) RemoveByIP(ipAddress net.IP) (*model.StaticDhcpHost, error) {
-	return s.repository.DeleteByIP(ipAddress)
+func (s *service) RemoveByMac(macAddress model.HardwareAddress, options Options) (current *model.StaticDhcpHost, err error) {
+	err = s.repository.Transaction(func(repository Repository) error {
+		current, err = repository.FindByMac(macAddress)
+		if err != nil {
+			return err
+		}
+
+		return remove(repository, current, options)
+	})
+	return current, err
+}
+
+func (s *service) RemoveByIP(ipAddress net.IP, options Options) (current *model.StaticDhcpHost, err error) {
+	err = s.repository.Transaction(func(repository Repository) error {
+		current, err = repository.FindByIP(ipAddress)
+		if err != nil {
+			return err
+		}
+
+		return remove(repository, current, options)
+	})
+	return current, err
+}
+
+func (s *service) RemoveByHostName(hostName string, options Options) (current *model.StaticDhcpHost, err error) {
+	err = s.repository.Transaction(func(repository Repository) error {
+		current, err = repository.FindByHostName(hostName)
+		if err != nil {
+			return err
+		}
+
+		return remove(repository, current, options)
+	})
+	return current, err
+}
+
+func remove(repository Repository, current *model.StaticDhcpHost, options Options) error {
+	if err := options.check(current); err != nil {
+		return err
+	}
+
+	if current == nil {
+		return nil
+	}
+
+	_, err := repository.Delete(current)
+	return err
+}
+
+// newDuplicatedEntryError reports the first field of host already used by the other host, if any.
+func newDuplicatedEntryError(host *model.StaticDhcpHost, other *model.StaticDhcpHost) error {
+	for _, mac := range host.MacAddresses {
+		if sameMacAddress(mac)(*other) {
+			return DuplicatedEntryError{Field: "MAC", Value: mac.String()}
+		}
+	}
+
+	if sameIPAddress(host.IPAddress)(*other) {
+		return DuplicatedEntryError{Field: "IP", Value: host.IPAddress.String()}
+	}
+
+	for _, address := range host.IPv6Addresses {
+		if sameIPv6Address(address)(*other) {
+			return DuplicatedEntryError{Field: "IP", Value: address.String()}
+		}
+	}
+
+	if sameHostName(host.HostName)(*other) {
+		return DuplicatedEntryError{Field: "HostName", Value: host.HostName}
+	}
+
+	return nil
 }
 
 type DuplicatedEntryError struct {
@@ -86,5 +308,8 @@ type DuplicatedEntryError struct {
 }
 
 func (e DuplicatedEntryError) Error() string {
+	if e.Field == "HostName" {
+		return fmt.Sprintf("Duplicated host name: %s", e.Value)
+	}
 	return fmt.Sprintf("Duplicated %s address: %s", e.Field, e.Value)
 }
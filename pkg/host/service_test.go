@@ -0,0 +1,61 @@
+package host
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+func newTestService(t *testing.T) Service {
+	path := filepath.Join(t.TempDir(), "hosts.conf")
+	return NewService(NewRepository(path, 0644, dnsmasq.FileWriter, nil))
+}
+
+func parseHost(t *testing.T, config string) *model.StaticDhcpHost {
+	host := &model.StaticDhcpHost{}
+	if err := host.FromConfig(config); err != nil {
+		t.Fatalf("FromConfig(%q) failed: %v", config, err)
+	}
+	return host
+}
+
+func TestServiceIPv6Conflicts(t *testing.T) {
+	tests := []struct {
+		name         string
+		first        string
+		second       string
+		wantConflict bool
+	}{
+		{name: "address within range", first: "dhcp-host=id:a,[fd00::5],a", second: "dhcp-host=id:b,[fd00::]/120,b", wantConflict: true},
+		{name: "range holding address", first: "dhcp-host=id:b,[fd00::]/120,b", second: "dhcp-host=id:a,[fd00::5],a", wantConflict: true},
+		{name: "address outside range", first: "dhcp-host=id:a,[fd00::1:5],a", second: "dhcp-host=id:b,[fd00::]/120,b"},
+		{name: "host identifier", first: "dhcp-host=id:a,[fd00::5],a", second: "dhcp-host=id:b,[::5],b", wantConflict: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, update := range []bool{false, true} {
+				service := newTestService(t)
+				if err := service.Insert(parseHost(t, tt.first)); err != nil {
+					t.Fatalf("Insert(%q) failed: %v", tt.first, err)
+				}
+
+				second := parseHost(t, tt.second)
+				var err error
+				if update {
+					_, err = service.UpdateByHostName(second.HostName, second, Options{})
+				} else {
+					err = service.Insert(second)
+				}
+
+				var duplicated DuplicatedEntryError
+				if conflict := errors.As(err, &duplicated); conflict != tt.wantConflict || (!conflict && err != nil) {
+					t.Errorf("update %v: adding %q after %q = %v, want conflict %v", update, tt.second, tt.first, err, tt.wantConflict)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,29 @@
+package lease
+
+import (
+	"net"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/gringolito/dnsmasq-manager/pkg/query"
+)
+
+// Record returns the queryable fields of a lease.
+func Record(lease model.Lease) query.Record {
+	record := query.Record{
+		IPAddresses: []net.IP{lease.IPAddress},
+		HostName:    lease.HostName,
+		// A client holds a single lease per address
+		Key: lease.IPAddress.String(),
+	}
+
+	if lease.MacAddress != "" {
+		record.MacAddresses = append(record.MacAddresses, lease.MacAddress.String())
+	}
+
+	return record
+}
+
+// Query returns the page of the leases selected by the query.
+func Query(leases []model.Lease, q query.Query) (*query.Page[model.Lease], error) {
+	return query.Apply(leases, Record, q)
+}
@@ -0,0 +1,86 @@
+package lease
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+// Repository reads the leases file, which is owned by dnsmasq and therefore never written.
+type Repository interface {
+	FindAll() (*[]model.Lease, error)
+	FindByMac(macAddress model.HardwareAddress) (*model.Lease, error)
+}
+
+type repository struct {
+	leasesFilePath string
+}
+
+func NewRepository(leasesFilePath string) Repository {
+	return &repository{
+		leasesFilePath: leasesFilePath,
+	}
+}
+
+func (r *repository) FindAll() (*[]model.Lease, error) {
+	return r.load()
+}
+
+func (r *repository) FindByMac(macAddress model.HardwareAddress) (*model.Lease, error) {
+	leases, err := r.load()
+	if err != nil {
+		return nil, err
+	}
+
+	i := slices.IndexFunc(*leases, func(lease model.Lease) bool {
+		return lease.MacAddress == macAddress
+	})
+	if i < 0 {
+		return nil, nil
+	}
+
+	return &(*leases)[i], nil
+}
+
+// load reads the leases, a missing file holds none since dnsmasq only creates it on the first lease.
+func (r *repository) load() (*[]model.Lease, error) {
+	data, err := os.ReadFile(r.leasesFilePath)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		slog.Error("Error reading the leases file",
+			slog.String("file", r.leasesFilePath),
+			slog.String("error", err.Error()),
+		)
+		return nil, err
+	}
+
+	leases := []model.Lease{}
+	ipv6 := false
+	for _, line := range strings.Split(string(data), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		if strings.HasPrefix(line, model.LeaseDuidKeyword+" ") {
+			ipv6 = true
+			continue
+		}
+
+		lease := model.Lease{}
+		if err := lease.FromConfig(line, ipv6); err != nil {
+			// The file is rewritten by dnsmasq at any time, a bad entry must not hide all the others
+			slog.Warn("Skipping invalid lease entry",
+				slog.String("entry", line),
+				slog.String("error", err.Error()),
+			)
+			continue
+		}
+
+		leases = append(leases, lease)
+	}
+
+	return &leases, nil
+}
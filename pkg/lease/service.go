@@ -0,0 +1,74 @@
+package lease
+
+import (
+	"errors"
+	"net"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/host"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+)
+
+type Service interface {
+	FetchAll() (*[]model.Lease, error)
+	FetchByMac(macAddress model.HardwareAddress) (*model.Lease, error)
+	// Promote reserves the address currently leased to the MAC address, by adding a static host
+	// with the MAC address, IP address and host name of the lease. The IP address and the host name
+	// are replaced by the given ones, when set.
+	Promote(macAddress model.HardwareAddress, ipAddress net.IP, hostName string) (*model.StaticDhcpHost, error)
+}
+
+var ErrLeaseNotFound = errors.New("DHCP lease not found")
+
+type service struct {
+	repository Repository
+	hosts      host.Service
+}
+
+func NewService(repository Repository, hosts host.Service) Service {
+	return &service{
+		repository: repository,
+		hosts:      hosts,
+	}
+}
+
+func (s *service) FetchAll() (*[]model.Lease, error) {
+	return s.repository.FindAll()
+}
+
+func (s *service) FetchByMac(macAddress model.HardwareAddress) (*model.Lease, error) {
+	return s.repository.FindByMac(macAddress)
+}
+
+func (s *service) Promote(macAddress model.HardwareAddress, ipAddress net.IP, hostName string) (*model.StaticDhcpHost, error) {
+	lease, err := s.repository.FindByMac(macAddress)
+	if err != nil {
+		return nil, err
+	}
+	if lease == nil {
+		return nil, ErrLeaseNotFound
+	}
+
+	if ipAddress == nil {
+		ipAddress = lease.IPAddress
+	}
+	// The host name of the lease is sent by the client, which may not be usable as the host name
+	if hostName == "" && model.IsHostName(lease.HostName) {
+		hostName = lease.HostName
+	}
+
+	h := &model.StaticDhcpHost{
+		MacAddresses: []model.HardwareAddress{lease.MacAddress},
+		HostName:     hostName,
+	}
+	if ipAddress.To4() != nil {
+		h.IPAddress = ipAddress
+	} else {
+		h.IPv6Addresses = []model.IPv6Address{{IP: ipAddress}}
+	}
+
+	if err := s.hosts.Insert(h); err != nil {
+		return nil, err
+	}
+
+	return h, nil
+}
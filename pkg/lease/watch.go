@@ -0,0 +1,108 @@
+package lease
+
+import (
+	"path/filepath"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+// Change is a lease granted, renewed or released by dnsmasq. Before is nil when the lease was
+// granted, and After is nil when it was released or expired.
+type Change struct {
+	Before *model.Lease
+	After  *model.Lease
+}
+
+// Changes returns the changes turning the before leases into the after ones, the leases being
+// paired by their IP address.
+func Changes(before []model.Lease, after []model.Lease) []Change {
+	changes := []Change{}
+	for i := range after {
+		j := slices.IndexFunc(before, sameIPAddress(&after[i]))
+		if j < 0 {
+			changes = append(changes, Change{After: &after[i]})
+			continue
+		}
+		if !sameLease(&before[j], &after[i]) {
+			changes = append(changes, Change{Before: &before[j], After: &after[i]})
+		}
+	}
+	for i := range before {
+		if !slices.ContainsFunc(after, sameIPAddress(&before[i])) {
+			changes = append(changes, Change{Before: &before[i]})
+		}
+	}
+
+	return changes
+}
+
+func sameIPAddress(lease *model.Lease) func(model.Lease) bool {
+	return func(other model.Lease) bool {
+		return lease.IPAddress.Equal(other.IPAddress)
+	}
+}
+
+func sameLease(lease *model.Lease, other *model.Lease) bool {
+	return lease.Expiry.Equal(other.Expiry) &&
+		lease.MacAddress == other.MacAddress &&
+		lease.IAID == other.IAID &&
+		lease.IPAddress.Equal(other.IPAddress) &&
+		lease.HostName == other.HostName &&
+		lease.ClientID == other.ClientID
+}
+
+// Watch calls fn with the leases changed by dnsmasq, every time it rewrites the leases file. The
+// directory is watched instead of the file itself, since dnsmasq replaces the file.
+func Watch(leasesFilePath string, repository Repository, fn func([]Change)) error {
+	leases, err := repository.FindAll()
+	if err != nil {
+		return err
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	file := filepath.Clean(leasesFilePath)
+	if err := watcher.Add(filepath.Dir(file)); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != file {
+					continue
+				}
+
+				current, err := repository.FindAll()
+				if err != nil {
+					continue
+				}
+				if changes := Changes(*leases, *current); len(changes) > 0 {
+					fn(changes)
+				}
+				leases = current
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Error("Error watching the leases file",
+					slog.String("file", file),
+					slog.String("error", err.Error()),
+				)
+			}
+		}
+	}()
+
+	return nil
+}
@@ -0,0 +1,147 @@
+package metrics
+
+import (
+	"net"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/host"
+	"github.com/gringolito/dnsmasq-manager/pkg/lease"
+	"github.com/gringolito/dnsmasq-manager/pkg/model"
+	"github.com/prometheus/client_golang/prometheus"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+// Prefix lengths of the subnets the reservations are counted by, when no subnet is given
+const (
+	defaultIPv4PrefixLength = 24
+	defaultIPv6PrefixLength = 64
+)
+
+var (
+	staticHostsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "static_hosts"),
+		"Number of static DHCP hosts.",
+		nil, nil,
+	)
+	reservationsDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "static_host_reservations"),
+		"Number of IP addresses reserved by the static DHCP hosts, by subnet.",
+		[]string{"subnet"}, nil,
+	)
+	leasesDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "leases"),
+		"Number of DHCP leases, by IP family.",
+		[]string{"family"}, nil,
+	)
+)
+
+type hostCollector struct {
+	service host.Service
+	subnets []*net.IPNet
+}
+
+// NewHostCollector counts the static hosts and their reserved IP addresses within each subnet, or
+// within the /24 (IPv4) or /64 (IPv6) subnet of each address when no subnet is given. The hosts
+// are read on every scrape.
+func NewHostCollector(service host.Service, subnets []*net.IPNet) prometheus.Collector {
+	return &hostCollector{
+		service: service,
+		subnets: subnets,
+	}
+}
+
+func (c *hostCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- staticHostsDesc
+	ch <- reservationsDesc
+}
+
+func (c *hostCollector) Collect(ch chan<- prometheus.Metric) {
+	hosts, err := c.service.FetchAll()
+	if err != nil {
+		slog.Error("Error collecting the static hosts metrics", slog.String("error", err.Error()))
+		ch <- prometheus.NewInvalidMetric(staticHostsDesc, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(staticHostsDesc, prometheus.GaugeValue, float64(len(*hosts)))
+
+	reservations := map[string]int{}
+	for _, subnet := range c.subnets {
+		reservations[subnet.String()] = 0
+	}
+	for _, h := range *hosts {
+		for _, ip := range addresses(&h) {
+			if subnet := c.subnet(ip); subnet != "" {
+				reservations[subnet]++
+			}
+		}
+	}
+	for subnet, count := range reservations {
+		ch <- prometheus.MustNewConstMetric(reservationsDesc, prometheus.GaugeValue, float64(count), subnet)
+	}
+}
+
+// subnet returns the subnet the address is counted within, if any.
+func (c *hostCollector) subnet(ip net.IP) string {
+	if len(c.subnets) > 0 {
+		i := slices.IndexFunc(c.subnets, func(subnet *net.IPNet) bool {
+			return subnet.Contains(ip)
+		})
+		if i < 0 {
+			return ""
+		}
+		return c.subnets[i].String()
+	}
+
+	mask := net.CIDRMask(defaultIPv6PrefixLength, 8*net.IPv6len)
+	if ip4 := ip.To4(); ip4 != nil {
+		ip, mask = ip4, net.CIDRMask(defaultIPv4PrefixLength, 8*net.IPv4len)
+	}
+
+	return (&net.IPNet{IP: ip.Mask(mask), Mask: mask}).String()
+}
+
+func addresses(h *model.StaticDhcpHost) []net.IP {
+	ips := []net.IP{}
+	if h.IPAddress != nil {
+		ips = append(ips, h.IPAddress)
+	}
+	for _, address := range h.IPv6Addresses {
+		ips = append(ips, address.IP)
+	}
+
+	return ips
+}
+
+type leaseCollector struct {
+	service lease.Service
+}
+
+// NewLeaseCollector counts the DHCP leases, which are read on every scrape.
+func NewLeaseCollector(service lease.Service) prometheus.Collector {
+	return &leaseCollector{service: service}
+}
+
+func (c *leaseCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- leasesDesc
+}
+
+func (c *leaseCollector) Collect(ch chan<- prometheus.Metric) {
+	leases, err := c.service.FetchAll()
+	if err != nil {
+		slog.Error("Error collecting the leases metrics", slog.String("error", err.Error()))
+		ch <- prometheus.NewInvalidMetric(leasesDesc, err)
+		return
+	}
+
+	ipv4, ipv6 := 0, 0
+	for _, l := range *leases {
+		if l.IPAddress.To4() != nil {
+			ipv4++
+		} else {
+			ipv6++
+		}
+	}
+	ch <- prometheus.MustNewConstMetric(leasesDesc, prometheus.GaugeValue, float64(ipv4), "ipv4")
+	ch <- prometheus.MustNewConstMetric(leasesDesc, prometheus.GaugeValue, float64(ipv6), "ipv6")
+}
@@ -0,0 +1,120 @@
+package metrics
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/dnsmasq"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "dnsmasq_manager"
+
+// Result label values
+const (
+	Success = "success"
+	Failure = "failure"
+)
+
+// Metrics holds the Prometheus metrics of the service, along with the metrics of the Go runtime and
+// of the process.
+type Metrics struct {
+	registry *prometheus.Registry
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	writes   *prometheus.CounterVec
+	reloads  *prometheus.CounterVec
+}
+
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests handled, by route name, method and status code.",
+		}, []string{"route", "method", "code"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Latency of the HTTP requests, by route name and method.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"route", "method"}),
+		writes: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "file_writes_total",
+			Help:      "Number of writes of the dnsmasq files, by file and result.",
+		}, []string{"file", "result"}),
+		reloads: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "reloads_total",
+			Help:      "Number of dnsmasq reloads, by result.",
+		}, []string{"result"}),
+	}
+
+	m.registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		m.requests,
+		m.duration,
+		m.writes,
+		m.reloads,
+	)
+
+	return m
+}
+
+// Register adds the collectors to the metrics.
+func (m *Metrics) Register(collectors ...prometheus.Collector) error {
+	for _, collector := range collectors {
+		if err := m.registry.Register(collector); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Handler serves the metrics, in the OpenMetrics format when the client accepts it.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{
+		EnableOpenMetrics: true,
+	})
+}
+
+// ObserveRequest records a handled HTTP request.
+func (m *Metrics) ObserveRequest(route string, method string, code int, duration time.Duration) {
+	m.requests.WithLabelValues(route, method, strconv.Itoa(code)).Inc()
+	m.duration.WithLabelValues(route, method).Observe(duration.Seconds())
+}
+
+// Writer counts the writes of the files, by their name.
+func (m *Metrics) Writer(next dnsmasq.Writer) dnsmasq.Writer {
+	return dnsmasq.WriterFunc(func(path string, data []byte, mode os.FileMode) error {
+		err := next.WriteFile(path, data, mode)
+		m.writes.WithLabelValues(filepath.Base(path), result(err)).Inc()
+		return err
+	})
+}
+
+// Reloader counts the reloads of dnsmasq.
+func (m *Metrics) Reloader(next dnsmasq.Reloader) dnsmasq.Reloader {
+	return dnsmasq.ReloaderFunc(func() error {
+		err := next.Reload()
+		m.reloads.WithLabelValues(result(err)).Inc()
+		return err
+	})
+}
+
+func result(err error) string {
+	if err != nil {
+		return Failure
+	}
+
+	return Success
+}
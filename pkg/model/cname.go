@@ -0,0 +1,80 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Cname mirrors a dnsmasq cname entry:
+//
+//	cname=<cname>[,<cname>...],<target>[,<TTL>]
+type Cname struct {
+	Aliases []string
+	Target  string
+	// TTL is the time to live of the aliases, in seconds, or 0 for the dnsmasq default
+	TTL int
+}
+
+const cnamePrefix = "cname="
+
+var ErrInvalidCname = errors.New("invalid CNAME entry")
+
+func (c *Cname) FromConfig(config string) error {
+	value, found := strings.CutPrefix(strings.TrimSpace(config), cnamePrefix)
+	if !found {
+		return ErrInvalidCname
+	}
+
+	tokens := []string{}
+	for _, token := range strings.Split(value, ",") {
+		if token = strings.TrimSpace(token); token != "" {
+			tokens = append(tokens, token)
+		}
+	}
+
+	*c = Cname{}
+	if len(tokens) > 2 {
+		if ttl, err := strconv.Atoi(tokens[len(tokens)-1]); err == nil {
+			if ttl < 0 {
+				return fmt.Errorf("%w: invalid TTL: %d", ErrInvalidCname, ttl)
+			}
+			c.TTL = ttl
+			tokens = tokens[:len(tokens)-1]
+		}
+	}
+
+	if len(tokens) < 2 {
+		return fmt.Errorf("%w: an alias and a target are required: %s", ErrInvalidCname, value)
+	}
+
+	c.Aliases = tokens[:len(tokens)-1]
+	c.Target = tokens[len(tokens)-1]
+
+	return nil
+}
+
+func (c *Cname) ToConfig() string {
+	tokens := append(slices.Clone(c.Aliases), c.Target)
+	if c.TTL > 0 {
+		tokens = append(tokens, strconv.Itoa(c.TTL))
+	}
+
+	return cnamePrefix + strings.Join(tokens, ",")
+}
+
+// HasAlias reports whether name is any of the aliases, case insensitively.
+func (c *Cname) HasAlias(name string) bool {
+	return slices.ContainsFunc(c.Aliases, func(alias string) bool {
+		return strings.EqualFold(name, alias)
+	})
+}
+
+func (c *Cname) Equal(other Cname) bool {
+	return slices.Equal(c.Aliases, other.Aliases) &&
+		c.Target == other.Target &&
+		c.TTL == other.TTL
+}
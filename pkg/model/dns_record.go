@@ -0,0 +1,251 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+type DnsRecordType string
+
+// DnsRecord mirrors one of the dnsmasq static DNS directives:
+//
+//	host-record=<name>[,<name>...],[<IPv4-address>],[<IPv6-address>][,<TTL>]
+//	address=/<domain>[/<domain>...]/[<ipaddr>|#]
+//	ptr-record=<name>[,<target>]
+type DnsRecord struct {
+	Type DnsRecordType
+	// Names are the names of a host-record, the domains of an address or the name of a ptr-record
+	Names []string
+	// Addresses are the IPv4 and IPv6 addresses of a host-record or of an address. An address
+	// without any answers the domains as local ones, while both the unspecified IPv4 and IPv6
+	// addresses stand for the dnsmasq # keyword. An address holding both an IPv4 and an IPv6
+	// address is written as one address directive per family.
+	Addresses []net.IP
+	// Target is the target name of a ptr-record
+	Target string
+	// TTL is the time to live of a host-record, in seconds, or 0 for the dnsmasq default
+	TTL int
+}
+
+// DnsRecord types, named after their dnsmasq directives
+const (
+	HostRecord DnsRecordType = "host-record"
+	Address    DnsRecordType = "address"
+	PtrRecord  DnsRecordType = "ptr-record"
+)
+
+const nullAddressKeyword = "#"
+
+var ErrInvalidDnsRecord = errors.New("invalid DNS record")
+
+// DnsRecordTypes lists the types of the managed DNS records.
+var DnsRecordTypes = []DnsRecordType{HostRecord, Address, PtrRecord}
+
+func IsDnsRecordType(value string) bool {
+	return slices.Contains(DnsRecordTypes, DnsRecordType(value))
+}
+
+// FromConfig parses the value of one of the DNS record directives.
+func (r *DnsRecord) FromConfig(recordType DnsRecordType, value string) error {
+	*r = DnsRecord{Type: recordType}
+
+	switch recordType {
+	case HostRecord:
+		return r.parseHostRecord(value)
+	case Address:
+		return r.parseAddress(value)
+	case PtrRecord:
+		return r.parsePtrRecord(value)
+	default:
+		return fmt.Errorf("%w: unknown type: %s", ErrInvalidDnsRecord, recordType)
+	}
+}
+
+func (r *DnsRecord) parseHostRecord(value string) error {
+	tokens := strings.Split(value, ",")
+	for i, token := range tokens {
+		token = strings.TrimSpace(token)
+		switch {
+		case token == "":
+			continue
+		case net.ParseIP(token) != nil:
+			r.Addresses = append(r.Addresses, net.ParseIP(token))
+		case i == len(tokens)-1 && len(r.Addresses) > 0:
+			ttl, err := strconv.Atoi(token)
+			if err != nil || ttl < 0 {
+				return fmt.Errorf("%w: invalid TTL: %s", ErrInvalidDnsRecord, token)
+			}
+			r.TTL = ttl
+		case len(r.Addresses) > 0:
+			return fmt.Errorf("%w: name after the addresses: %s", ErrInvalidDnsRecord, token)
+		default:
+			r.Names = append(r.Names, token)
+		}
+	}
+
+	return nil
+}
+
+func (r *DnsRecord) parseAddress(value string) error {
+	value = strings.TrimSpace(value)
+	if !strings.HasPrefix(value, "/") {
+		return fmt.Errorf("%w: missing domain: %s", ErrInvalidDnsRecord, value)
+	}
+
+	tokens := strings.Split(value[1:], "/")
+	for _, domain := range tokens[:len(tokens)-1] {
+		if domain != "" {
+			r.Names = append(r.Names, domain)
+		}
+	}
+
+	switch address := tokens[len(tokens)-1]; address {
+	case "":
+	case nullAddressKeyword:
+		r.Addresses = []net.IP{net.IPv4zero, net.IPv6unspecified}
+	default:
+		ip := net.ParseIP(address)
+		if ip == nil {
+			return fmt.Errorf("%w: invalid address: %s", ErrInvalidDnsRecord, address)
+		}
+		r.Addresses = []net.IP{ip}
+	}
+
+	return nil
+}
+
+func (r *DnsRecord) parsePtrRecord(value string) error {
+	name, target, _ := strings.Cut(value, ",")
+	r.Names = []string{strings.TrimSpace(name)}
+	r.Target = strings.TrimSpace(target)
+
+	return nil
+}
+
+// Validate reports the fields that can not be used together with the type of the record.
+func (r *DnsRecord) Validate() error {
+	if len(r.Names) == 0 {
+		return fmt.Errorf("%w: at least one name is required", ErrInvalidDnsRecord)
+	}
+	if r.Type != HostRecord && r.TTL != 0 {
+		return fmt.Errorf("%w: only a %s has a TTL", ErrInvalidDnsRecord, HostRecord)
+	}
+	if r.Type != PtrRecord && r.Target != "" {
+		return fmt.Errorf("%w: only a %s has a target", ErrInvalidDnsRecord, PtrRecord)
+	}
+
+	switch r.Type {
+	case HostRecord:
+		ipv4 := slices.IndexFunc(r.Addresses, isIPv4)
+		ipv6 := slices.IndexFunc(r.Addresses, func(ip net.IP) bool { return !isIPv4(ip) })
+		if len(r.Addresses) == 0 || len(r.Addresses) > 2 || (len(r.Addresses) == 2 && (ipv4 < 0 || ipv6 < 0)) {
+			return fmt.Errorf("%w: a %s requires an IPv4 address, an IPv6 address or both", ErrInvalidDnsRecord, r.Type)
+		}
+	case Address:
+		if len(r.Addresses) > 2 || (len(r.Addresses) == 2 && !r.isDualStack()) {
+			return fmt.Errorf("%w: an %s has at most an IPv4 and an IPv6 address", ErrInvalidDnsRecord, r.Type)
+		}
+	case PtrRecord:
+		if len(r.Names) > 1 || len(r.Addresses) > 0 {
+			return fmt.Errorf("%w: a %s has a single name and no addresses", ErrInvalidDnsRecord, r.Type)
+		}
+	default:
+		return fmt.Errorf("%w: unknown type: %s", ErrInvalidDnsRecord, r.Type)
+	}
+
+	return nil
+}
+
+// ToConfig returns the dnsmasq directive of the record, addresses in IPv4, IPv6 order. A dual-stack
+// address is returned as two directives, one per line.
+func (r *DnsRecord) ToConfig() string {
+	switch r.Type {
+	case HostRecord:
+		tokens := slices.Clone(r.Names)
+		for _, address := range r.sortedAddresses() {
+			tokens = append(tokens, address.String())
+		}
+		if r.TTL > 0 {
+			tokens = append(tokens, strconv.Itoa(r.TTL))
+		}
+		return fmt.Sprintf("%s=%s", r.Type, strings.Join(tokens, ","))
+	case Address:
+		domains := strings.Join(r.Names, "/")
+		switch {
+		case r.isNullAddress():
+			return fmt.Sprintf("%s=/%s/%s", r.Type, domains, nullAddressKeyword)
+		case len(r.Addresses) == 0:
+			return fmt.Sprintf("%s=/%s/", r.Type, domains)
+		}
+		lines := make([]string, 0, len(r.Addresses))
+		for _, address := range r.sortedAddresses() {
+			lines = append(lines, fmt.Sprintf("%s=/%s/%s", r.Type, domains, address))
+		}
+		return strings.Join(lines, "\n")
+	default:
+		config := fmt.Sprintf("%s=%s", r.Type, strings.Join(r.Names, ","))
+		if r.Target != "" {
+			config += "," + r.Target
+		}
+		return config
+	}
+}
+
+// Merge folds the address directive of the other family of the same domains into the record,
+// reporting whether it did, as a dual-stack address is written as one directive per family.
+func (r *DnsRecord) Merge(other *DnsRecord) bool {
+	if r.Type != Address || other.Type != Address || !slices.Equal(r.Names, other.Names) ||
+		len(r.Addresses) != 1 || len(other.Addresses) != 1 {
+		return false
+	}
+
+	merged := DnsRecord{Type: Address, Names: r.Names, Addresses: []net.IP{r.Addresses[0], other.Addresses[0]}}
+	if !merged.isDualStack() || merged.isNullAddress() {
+		// Null answers are only merged when written with the # keyword
+		return false
+	}
+
+	r.Addresses = merged.Addresses
+	return true
+}
+
+// HasName reports whether name is any of the names of the record, case insensitively.
+func (r *DnsRecord) HasName(name string) bool {
+	return slices.ContainsFunc(r.Names, func(other string) bool {
+		return strings.EqualFold(name, other)
+	})
+}
+
+func (r *DnsRecord) Equal(other DnsRecord) bool {
+	return r.Type == other.Type &&
+		slices.Equal(r.Names, other.Names) &&
+		slices.EqualFunc(r.Addresses, other.Addresses, net.IP.Equal) &&
+		r.Target == other.Target &&
+		r.TTL == other.TTL
+}
+
+func (r *DnsRecord) isNullAddress() bool {
+	return r.isDualStack() && r.Addresses[0].IsUnspecified() && r.Addresses[1].IsUnspecified()
+}
+
+// isDualStack reports whether the record holds an IPv4 and an IPv6 address.
+func (r *DnsRecord) isDualStack() bool {
+	return len(r.Addresses) == 2 && isIPv4(r.Addresses[0]) != isIPv4(r.Addresses[1])
+}
+
+func (r *DnsRecord) sortedAddresses() []net.IP {
+	addresses := slices.Clone(r.Addresses)
+	slices.SortStableFunc(addresses, func(a, b net.IP) bool {
+		return isIPv4(a) && !isIPv4(b)
+	})
+	return addresses
+}
+
+func isIPv4(ip net.IP) bool {
+	return ip.To4() != nil
+}
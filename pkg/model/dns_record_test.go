@@ -0,0 +1,116 @@
+package model
+
+import (
+	"net"
+	"testing"
+)
+
+func TestDnsRecordToConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		record DnsRecord
+		want   string
+	}{
+		{
+			name:   "host-record",
+			record: DnsRecord{Type: HostRecord, Names: []string{"nas.lan", "nas"}, Addresses: []net.IP{net.ParseIP("fd00::10"), net.ParseIP("192.168.1.10")}, TTL: 300},
+			want:   "host-record=nas.lan,nas,192.168.1.10,fd00::10,300",
+		},
+		{
+			name:   "address",
+			record: DnsRecord{Type: Address, Names: []string{"nas.lan"}, Addresses: []net.IP{net.ParseIP("192.168.1.10")}},
+			want:   "address=/nas.lan/192.168.1.10",
+		},
+		{
+			name:   "dual-stack address",
+			record: DnsRecord{Type: Address, Names: []string{"nas.lan", "nas.home"}, Addresses: []net.IP{net.ParseIP("fd00::10"), net.ParseIP("192.168.1.10")}},
+			want:   "address=/nas.lan/nas.home/192.168.1.10\naddress=/nas.lan/nas.home/fd00::10",
+		},
+		{
+			name:   "null address",
+			record: DnsRecord{Type: Address, Names: []string{"ads.example.com"}, Addresses: []net.IP{net.IPv4zero, net.IPv6unspecified}},
+			want:   "address=/ads.example.com/#",
+		},
+		{
+			name:   "local address",
+			record: DnsRecord{Type: Address, Names: []string{"lan"}},
+			want:   "address=/lan/",
+		},
+		{
+			name:   "ptr-record",
+			record: DnsRecord{Type: PtrRecord, Names: []string{"10.1.168.192.in-addr.arpa"}, Target: "nas.lan"},
+			want:   "ptr-record=10.1.168.192.in-addr.arpa,nas.lan",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.record.Validate(); err != nil {
+				t.Fatalf("Validate() failed: %v", err)
+			}
+			if got := tt.record.ToConfig(); got != tt.want {
+				t.Errorf("ToConfig() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDnsRecordValidateAddress(t *testing.T) {
+	tests := []struct {
+		name      string
+		addresses []string
+		valid     bool
+	}{
+		{name: "IPv4 and IPv6", addresses: []string{"192.168.1.10", "fd00::10"}, valid: true},
+		{name: "two IPv4", addresses: []string{"192.168.1.10", "192.168.1.11"}},
+		{name: "two IPv6", addresses: []string{"fd00::10", "fd00::11"}},
+		{name: "three", addresses: []string{"192.168.1.10", "fd00::10", "fd00::11"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := DnsRecord{Type: Address, Names: []string{"nas.lan"}}
+			for _, address := range tt.addresses {
+				record.Addresses = append(record.Addresses, net.ParseIP(address))
+			}
+
+			if err := record.Validate(); (err == nil) != tt.valid {
+				t.Errorf("Validate() = %v, want valid %v", err, tt.valid)
+			}
+		})
+	}
+}
+
+func TestDnsRecordMerge(t *testing.T) {
+	parse := func(value string) *DnsRecord {
+		record := &DnsRecord{}
+		if err := record.FromConfig(Address, value); err != nil {
+			t.Fatalf("FromConfig(%q) failed: %v", value, err)
+		}
+		return record
+	}
+
+	tests := []struct {
+		name   string
+		a, b   string
+		merged bool
+	}{
+		{name: "other family", a: "/nas.lan/192.168.1.10", b: "/nas.lan/fd00::10", merged: true},
+		{name: "same family", a: "/nas.lan/192.168.1.10", b: "/nas.lan/192.168.1.11"},
+		{name: "other domains", a: "/nas.lan/192.168.1.10", b: "/nas.home/fd00::10"},
+		{name: "null answers", a: "/ads.lan/0.0.0.0", b: "/ads.lan/::"},
+		{name: "null keyword", a: "/ads.lan/#", b: "/ads.lan/fd00::10"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a, b := parse(tt.a), parse(tt.b)
+			if got := a.Merge(b); got != tt.merged {
+				t.Fatalf("Merge() = %v, want %v", got, tt.merged)
+			}
+			if tt.merged && len(a.Addresses) != 2 {
+				t.Errorf("Merge() addresses = %v, want both", a.Addresses)
+			}
+		})
+	}
+}
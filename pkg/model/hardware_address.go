@@ -0,0 +1,58 @@
+package model
+
+import (
+	"errors"
+	"net"
+	"regexp"
+	"strings"
+)
+
+// HardwareAddress is a dnsmasq hardware address. Besides plain MAC addresses it may carry the
+// ARP hardware type as a prefix (e.g. 06-00:20:e0:3b:13:af) and '*' wildcard octets
+// (e.g. 00:20:e0:3b:13:*). It is always stored in the lowercase, colon separated form.
+type HardwareAddress string
+
+var ErrInvalidHardwareAddress = errors.New("invalid hardware address")
+
+var hardwareAddressRegexp = regexp.MustCompile(`^(?:([0-9a-f]{1,2})-)?((?:[0-9a-f]{1,2}|\*)(?::(?:[0-9a-f]{1,2}|\*)){1,15})$`)
+
+func ParseHardwareAddress(address string) (HardwareAddress, error) {
+	address = strings.ToLower(strings.TrimSpace(address))
+
+	// Dash or dot separated MAC addresses are only accepted in their plain form
+	if !strings.Contains(address, ":") {
+		mac, err := net.ParseMAC(address)
+		if err != nil {
+			return "", ErrInvalidHardwareAddress
+		}
+		return HardwareAddress(mac.String()), nil
+	}
+
+	matches := hardwareAddressRegexp.FindStringSubmatch(address)
+	if matches == nil {
+		return "", ErrInvalidHardwareAddress
+	}
+
+	octets := strings.Split(matches[2], ":")
+	for i, octet := range octets {
+		if len(octet) == 1 && octet != "*" {
+			octets[i] = "0" + octet
+		}
+	}
+
+	normalized := strings.Join(octets, ":")
+	if matches[1] != "" {
+		normalized = matches[1] + "-" + normalized
+	}
+
+	return HardwareAddress(normalized), nil
+}
+
+func (a HardwareAddress) String() string {
+	return string(a)
+}
+
+// IsWildcard reports whether the address matches more than a single hardware address.
+func (a HardwareAddress) IsWildcard() bool {
+	return strings.Contains(string(a), "*")
+}
@@ -0,0 +1,113 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// IPv6Address is an IPv6 address reserved by a dhcp-host entry. The address may hold only the
+// host identifier part (e.g. ::56), which dnsmasq combines with the prefix of the matching
+// dhcp-range, and may carry a prefix length to reserve a range of addresses.
+type IPv6Address struct {
+	IP           net.IP
+	PrefixLength int
+}
+
+var ErrInvalidIPv6Address = errors.New("invalid IPv6 address")
+
+// ParseIPv6Address parses both the dnsmasq form ([addr] or [addr]/len) and the plain form
+// (addr or addr/len) of an IPv6 address.
+func ParseIPv6Address(address string) (IPv6Address, error) {
+	address = strings.TrimSpace(address)
+
+	var prefixLength string
+	if strings.HasPrefix(address, "[") {
+		end := strings.Index(address, "]")
+		if end < 0 {
+			return IPv6Address{}, ErrInvalidIPv6Address
+		}
+
+		rest := address[end+1:]
+		address = address[1:end]
+		if rest != "" {
+			if !strings.HasPrefix(rest, "/") {
+				return IPv6Address{}, ErrInvalidIPv6Address
+			}
+			prefixLength = rest[1:]
+		}
+	} else if addr, length, found := strings.Cut(address, "/"); found {
+		address = addr
+		prefixLength = length
+	}
+
+	ip := net.ParseIP(address)
+	if ip == nil || ip.To4() != nil || !strings.Contains(address, ":") {
+		return IPv6Address{}, ErrInvalidIPv6Address
+	}
+
+	result := IPv6Address{IP: ip}
+	if prefixLength != "" {
+		length, err := strconv.Atoi(prefixLength)
+		if err != nil || length < 1 || length > 128 {
+			return IPv6Address{}, ErrInvalidIPv6Address
+		}
+		result.PrefixLength = length
+	}
+
+	return result, nil
+}
+
+// String returns the plain form of the address.
+func (a IPv6Address) String() string {
+	if a.PrefixLength > 0 {
+		return fmt.Sprintf("%s/%d", a.IP.String(), a.PrefixLength)
+	}
+	return a.IP.String()
+}
+
+// ToConfig returns the bracketed form of the address required by dnsmasq.
+func (a IPv6Address) ToConfig() string {
+	if a.PrefixLength > 0 {
+		return fmt.Sprintf("[%s]/%d", a.IP.String(), a.PrefixLength)
+	}
+	return fmt.Sprintf("[%s]", a.IP.String())
+}
+
+func (a IPv6Address) Equal(other IPv6Address) bool {
+	return a.IP.Equal(other.IP) && a.PrefixLength == other.PrefixLength
+}
+
+// Overlaps reports whether the addresses have any address in common. Unlike Contains it is
+// symmetric, a host identifier overlaps every full address ending with it, whichever is given.
+func (a IPv6Address) Overlaps(other IPv6Address) bool {
+	return a.Contains(other.IP) || other.Contains(a.IP)
+}
+
+// Contains reports whether ip is the reserved address, or is within the reserved range. An address
+// holding only the host identifier matches that identifier under any prefix.
+func (a IPv6Address) Contains(ip net.IP) bool {
+	if ip == nil || ip.To4() != nil {
+		return false
+	}
+
+	reserved := a.IP.To16()
+	ip = ip.To16()
+	if reserved == nil || ip == nil {
+		return false
+	}
+
+	if reserved.Mask(net.CIDRMask(64, 128)).IsUnspecified() {
+		// Only the host identifier is reserved, compare the lower 64 bits
+		ip = append(make(net.IP, 8), ip[8:]...)
+	}
+
+	if a.PrefixLength > 0 {
+		network := net.IPNet{IP: reserved, Mask: net.CIDRMask(a.PrefixLength, 128)}
+		return network.Contains(ip)
+	}
+
+	return reserved.Equal(ip)
+}
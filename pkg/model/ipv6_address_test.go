@@ -0,0 +1,40 @@
+package model
+
+import "testing"
+
+func TestIPv6AddressOverlaps(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{a: "fd00::1234", b: "fd00::1234", want: true},
+		{a: "fd00::1234", b: "fd00::1235", want: false},
+		{a: "fd00::1234", b: "[::1234]", want: true},
+		{a: "fd00:0:0:1::1234", b: "[::1234]", want: true},
+		{a: "fd00::1234", b: "[::1235]", want: false},
+		{a: "[::1234]", b: "[::1234]", want: true},
+		{a: "fd00::/120", b: "fd00::12", want: true},
+		{a: "fd00::/120", b: "fd00::1:12", want: false},
+		{a: "fd00::/120", b: "fd00::/112", want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.a+" "+tt.b, func(t *testing.T) {
+			a, err := ParseIPv6Address(tt.a)
+			if err != nil {
+				t.Fatalf("ParseIPv6Address(%q) failed: %v", tt.a, err)
+			}
+			b, err := ParseIPv6Address(tt.b)
+			if err != nil {
+				t.Fatalf("ParseIPv6Address(%q) failed: %v", tt.b, err)
+			}
+
+			if got := a.Overlaps(b); got != tt.want {
+				t.Errorf("%s.Overlaps(%s) = %v, want %v", tt.a, tt.b, got, tt.want)
+			}
+			if got := b.Overlaps(a); got != tt.want {
+				t.Errorf("%s.Overlaps(%s) = %v, want %v", tt.b, tt.a, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,75 @@
+package model
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Lease mirrors an entry of the dnsmasq leases file:
+//
+//	<expiry> <hwaddr> <ipaddr> <hostname|*> <client_id|*>
+//
+// IPv6 leases follow the "duid <server_duid>" line, and hold the IAID in place of the hardware
+// address.
+type Lease struct {
+	// Expiry is the time the lease expires at, or the zero time for an infinite lease
+	Expiry     time.Time
+	MacAddress HardwareAddress
+	IAID       string
+	IPAddress  net.IP
+	HostName   string
+	ClientID   string
+}
+
+const (
+	leaseUnknownField = "*"
+	LeaseDuidKeyword  = "duid"
+)
+
+var ErrInvalidLease = errors.New("invalid lease entry")
+
+// FromConfig parses a line of the leases file. An IPv6 lease is parsed when ipv6 is set, that is,
+// when the line follows the server DUID line.
+func (l *Lease) FromConfig(line string, ipv6 bool) error {
+	fields := strings.Fields(line)
+	if len(fields) != 5 {
+		return fmt.Errorf("%w: %s", ErrInvalidLease, line)
+	}
+
+	*l = Lease{}
+
+	expiry, err := strconv.ParseInt(fields[0], 10, 64)
+	if err != nil {
+		return fmt.Errorf("%w: invalid expiry: %s", ErrInvalidLease, fields[0])
+	}
+	if expiry > 0 {
+		l.Expiry = time.Unix(expiry, 0)
+	}
+
+	if ipv6 {
+		l.IAID = fields[1]
+	} else if l.MacAddress, err = ParseHardwareAddress(fields[1]); err != nil {
+		return fmt.Errorf("%w: %s: %s", ErrInvalidLease, err.Error(), fields[1])
+	}
+
+	if l.IPAddress = net.ParseIP(fields[2]); l.IPAddress == nil {
+		return fmt.Errorf("%w: invalid IP address: %s", ErrInvalidLease, fields[2])
+	}
+
+	if fields[3] != leaseUnknownField {
+		l.HostName = fields[3]
+	}
+	if fields[4] != leaseUnknownField {
+		l.ClientID = fields[4]
+	}
+
+	return nil
+}
+
+func (l *Lease) IsInfinite() bool {
+	return l.Expiry.IsZero()
+}
@@ -1,44 +1,217 @@
 package model
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"net"
+	"regexp"
 	"strings"
+
+	"golang.org/x/exp/slices"
 )
 
+// StaticDhcpHost mirrors a dnsmasq dhcp-host entry:
+//
+//	dhcp-host=[<hwaddr>][,id:<client_id>|*][,set:<tag>][,tag:<tag>][,<ipaddr>][,[<ipv6addr>]][,<hostname>][,<lease_time>][,ignore]
 type StaticDhcpHost struct {
-	MacAddress net.HardwareAddr
-	IPAddress  net.IP
-	HostName   string
+	MacAddresses  []HardwareAddress
+	ClientID      string
+	SetTags       []string
+	Tags          []string
+	IPAddress     net.IP
+	IPv6Addresses []IPv6Address
+	HostName      string
+	LeaseTime     string
+	Ignore        bool
 }
 
+const (
+	dhcpHostPrefix = "dhcp-host="
+	clientIDPrefix = "id:"
+	setTagPrefix   = "set:"
+	netTagPrefix   = "net:" // Deprecated alias of set:
+	tagPrefix      = "tag:"
+	ignoreKeyword  = "ignore"
+	InfiniteLease  = "infinite"
+)
+
 var ErrInvalidDHCPHost = errors.New("invalid DHCP host entry")
 
+var leaseTimeRegexp = regexp.MustCompile(`^[0-9]+[smhdw]?$`)
+
+// IsLeaseTime reports whether the given value is a valid dnsmasq lease time.
+func IsLeaseTime(value string) bool {
+	return value == InfiniteLease || leaseTimeRegexp.MatchString(value)
+}
+
+// IsHostName reports whether the given value is read back as the host name of an entry, which is
+// neither the ignore keyword nor a value looking like a lease time (e.g. 12h or infinite).
+func IsHostName(value string) bool {
+	return value != ignoreKeyword && !IsLeaseTime(value)
+}
+
+// FromConfig parses the entry the way dnsmasq does. The tokens are told apart by their prefix
+// (id:, set:, tag:, [ipv6addr]) or by their form (IPv4 and hardware addresses), while the remaining
+// ones are told apart by their position, the lease time following the host name.
 func (h *StaticDhcpHost) FromConfig(config string) error {
-	tokens := strings.Split(config, ",")
-	if len(tokens) != 3 {
+	value, found := strings.CutPrefix(strings.TrimSpace(config), dhcpHostPrefix)
+	if !found {
 		return ErrInvalidDHCPHost
 	}
 
-	var mac string
-	_, err := fmt.Sscanf(tokens[0], "dhcp-host=%s", &mac)
-	if err != nil {
-		return err
+	*h = StaticDhcpHost{}
+	var names []string
+	for _, token := range strings.Split(value, ",") {
+		token = strings.TrimSpace(token)
+		named, err := h.parseToken(token)
+		if err != nil {
+			return err
+		}
+		if named {
+			names = append(names, token)
+		}
+	}
+
+	return h.parseNames(names)
+}
+
+// parseToken parses the token, unless it is either the host name or the lease time.
+func (h *StaticDhcpHost) parseToken(token string) (bool, error) {
+	switch {
+	case token == "":
+		return false, nil
+	case strings.HasPrefix(token, clientIDPrefix):
+		if h.ClientID != "" {
+			return false, duplicatedTokenError("client ID", token)
+		}
+		h.ClientID = strings.TrimPrefix(token, clientIDPrefix)
+	case strings.HasPrefix(token, setTagPrefix), strings.HasPrefix(token, netTagPrefix):
+		h.SetTags = append(h.SetTags, token[len(setTagPrefix):])
+	case strings.HasPrefix(token, tagPrefix):
+		h.Tags = append(h.Tags, strings.TrimPrefix(token, tagPrefix))
+	case strings.HasPrefix(token, "["):
+		address, err := ParseIPv6Address(token)
+		if err != nil {
+			return false, fmt.Errorf("%w: %s: %s", ErrInvalidDHCPHost, err.Error(), token)
+		}
+		h.IPv6Addresses = append(h.IPv6Addresses, address)
+	case token == ignoreKeyword:
+		h.Ignore = true
+	case net.ParseIP(token).To4() != nil:
+		if h.IPAddress != nil {
+			return false, duplicatedTokenError("IPv4 address", token)
+		}
+		h.IPAddress = net.ParseIP(token)
+	case net.ParseIP(token) != nil:
+		// Host names never hold a ':', neither do IPv6 addresses outside of their brackets
+		return false, fmt.Errorf("%w: IPv6 address out of brackets: %s", ErrInvalidDHCPHost, token)
+	case strings.Contains(token, ":"):
+		address, err := ParseHardwareAddress(token)
+		if err != nil {
+			return false, fmt.Errorf("%w: %s: %s", ErrInvalidDHCPHost, err.Error(), token)
+		}
+		h.MacAddresses = append(h.MacAddresses, address)
+	default:
+		return true, nil
 	}
 
-	h.MacAddress, err = net.ParseMAC(mac)
-	h.IPAddress = net.ParseIP(tokens[1])
-	h.HostName = tokens[2]
+	return false, nil
+}
 
-	return err
+// parseNames tells the host name from the lease time by their position. Out of two tokens the
+// lease time is the last one, unless only the first one looks like a lease time, while a single
+// token is read as the lease time whenever it looks like one, as dnsmasq reads it. Thus a host name
+// looking like a lease time (e.g. 1d) is only kept when it is followed by the lease time.
+func (h *StaticDhcpHost) parseNames(tokens []string) error {
+	switch len(tokens) {
+	case 0:
+	case 1:
+		if IsLeaseTime(tokens[0]) {
+			h.LeaseTime = tokens[0]
+		} else {
+			h.HostName = tokens[0]
+		}
+	case 2:
+		switch {
+		case IsLeaseTime(tokens[1]):
+			h.HostName, h.LeaseTime = tokens[0], tokens[1]
+		case IsLeaseTime(tokens[0]):
+			h.LeaseTime, h.HostName = tokens[0], tokens[1]
+		default:
+			return duplicatedTokenError("host name", tokens[1])
+		}
+	default:
+		return duplicatedTokenError("host name or lease time", tokens[2])
+	}
+
+	return nil
+}
+
+func duplicatedTokenError(field string, token string) error {
+	return fmt.Errorf("%w: more than one %s: %s", ErrInvalidDHCPHost, field, token)
 }
 
 func (h *StaticDhcpHost) ToConfig() string {
-	return fmt.Sprintf("dhcp-host=%s,%s,%s", h.MacAddress.String(), h.IPAddress.String(), h.HostName)
+	tokens := make([]string, 0, len(h.MacAddresses)+len(h.SetTags)+len(h.Tags)+len(h.IPv6Addresses)+5)
+	for _, mac := range h.MacAddresses {
+		tokens = append(tokens, mac.String())
+	}
+	if h.ClientID != "" {
+		tokens = append(tokens, clientIDPrefix+h.ClientID)
+	}
+	for _, tag := range h.SetTags {
+		tokens = append(tokens, setTagPrefix+tag)
+	}
+	for _, tag := range h.Tags {
+		tokens = append(tokens, tagPrefix+tag)
+	}
+	if h.IPAddress != nil {
+		tokens = append(tokens, h.IPAddress.String())
+	}
+	for _, address := range h.IPv6Addresses {
+		tokens = append(tokens, address.ToConfig())
+	}
+	if h.HostName != "" {
+		tokens = append(tokens, h.HostName)
+	}
+	if h.LeaseTime != "" {
+		tokens = append(tokens, h.LeaseTime)
+	}
+	if h.Ignore {
+		tokens = append(tokens, ignoreKeyword)
+	}
+
+	return dhcpHostPrefix + strings.Join(tokens, ",")
 }
 
 func (h *StaticDhcpHost) Equal(other StaticDhcpHost) bool {
-	return bytes.Equal(h.MacAddress, other.MacAddress) && bytes.Equal(h.IPAddress, other.IPAddress) && h.HostName == other.HostName
+	return slices.Equal(h.MacAddresses, other.MacAddresses) &&
+		h.ClientID == other.ClientID &&
+		slices.Equal(h.SetTags, other.SetTags) &&
+		slices.Equal(h.Tags, other.Tags) &&
+		h.IPAddress.Equal(other.IPAddress) &&
+		slices.EqualFunc(h.IPv6Addresses, other.IPv6Addresses, IPv6Address.Equal) &&
+		h.HostName == other.HostName &&
+		h.LeaseTime == other.LeaseTime &&
+		h.Ignore == other.Ignore
+}
+
+// HasIPAddress reports whether ip is reserved by the entry, either as its IPv4 address or as any
+// of its IPv6 addresses. An IPv6 address holding only the host identifier matches every reserved
+// address ending with it.
+func (h *StaticDhcpHost) HasIPAddress(ip net.IP) bool {
+	if ip == nil {
+		return false
+	}
+
+	if ip.To4() != nil {
+		return ip.Equal(h.IPAddress)
+	}
+
+	return h.HasIPv6Address(IPv6Address{IP: ip})
+}
+
+// HasIPv6Address reports whether the address overlaps any of the IPv6 addresses of the entry.
+func (h *StaticDhcpHost) HasIPv6Address(address IPv6Address) bool {
+	return slices.ContainsFunc(h.IPv6Addresses, address.Overlaps)
 }
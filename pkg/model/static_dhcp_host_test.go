@@ -0,0 +1,183 @@
+package model
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestStaticDhcpHostFromConfig(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   StaticDhcpHost
+	}{
+		{
+			name:   "MAC, IPv4 address and host name",
+			config: "dhcp-host=00:20:e0:3b:13:af,192.168.1.10,nas",
+			want: StaticDhcpHost{
+				MacAddresses: []HardwareAddress{"00:20:e0:3b:13:af"},
+				IPAddress:    net.ParseIP("192.168.1.10"),
+				HostName:     "nas",
+			},
+		},
+		{
+			name:   "every token",
+			config: "dhcp-host=00:20:e0:3b:13:af,06-00:20:e0:3b:13:*,id:01:02:03,set:known,tag:lan,192.168.1.10,[fd00::10],[::56]/120,nas,12h,ignore",
+			want: StaticDhcpHost{
+				MacAddresses:  []HardwareAddress{"00:20:e0:3b:13:af", "06-00:20:e0:3b:13:*"},
+				ClientID:      "01:02:03",
+				SetTags:       []string{"known"},
+				Tags:          []string{"lan"},
+				IPAddress:     net.ParseIP("192.168.1.10"),
+				IPv6Addresses: []IPv6Address{{IP: net.ParseIP("fd00::10")}, {IP: net.ParseIP("::56"), PrefixLength: 120}},
+				HostName:      "nas",
+				LeaseTime:     "12h",
+				Ignore:        true,
+			},
+		},
+		{
+			name:   "deprecated net: tag",
+			config: "dhcp-host=net:known,nas",
+			want:   StaticDhcpHost{SetTags: []string{"known"}, HostName: "nas"},
+		},
+		{
+			name:   "tags holding a ':'",
+			config: "dhcp-host=set:fd00::1,tag:v6:lan,nas",
+			want:   StaticDhcpHost{SetTags: []string{"fd00::1"}, Tags: []string{"v6:lan"}, HostName: "nas"},
+		},
+		{
+			name:   "lone lease time",
+			config: "dhcp-host=00:20:e0:3b:13:af,infinite",
+			want:   StaticDhcpHost{MacAddresses: []HardwareAddress{"00:20:e0:3b:13:af"}, LeaseTime: InfiniteLease},
+		},
+		{
+			name:   "lone numeric token is a lease time",
+			config: "dhcp-host=00:20:e0:3b:13:af,42",
+			want:   StaticDhcpHost{MacAddresses: []HardwareAddress{"00:20:e0:3b:13:af"}, LeaseTime: "42"},
+		},
+		{
+			name:   "host name looking like a lease time followed by the lease time",
+			config: "dhcp-host=00:20:e0:3b:13:af,1d,2h",
+			want:   StaticDhcpHost{MacAddresses: []HardwareAddress{"00:20:e0:3b:13:af"}, HostName: "1d", LeaseTime: "2h"},
+		},
+		{
+			name:   "numeric host name followed by the lease time",
+			config: "dhcp-host=00:20:e0:3b:13:af,42,infinite",
+			want:   StaticDhcpHost{MacAddresses: []HardwareAddress{"00:20:e0:3b:13:af"}, HostName: "42", LeaseTime: InfiniteLease},
+		},
+		{
+			name:   "lease time before the host name",
+			config: "dhcp-host=00:20:e0:3b:13:af,1d,nas",
+			want:   StaticDhcpHost{MacAddresses: []HardwareAddress{"00:20:e0:3b:13:af"}, HostName: "nas", LeaseTime: "1d"},
+		},
+		{
+			name:   "blank tokens and spaces",
+			config: "  dhcp-host= 00:20:e0:3b:13:af , ,nas ",
+			want:   StaticDhcpHost{MacAddresses: []HardwareAddress{"00:20:e0:3b:13:af"}, HostName: "nas"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got StaticDhcpHost
+			if err := got.FromConfig(tt.config); err != nil {
+				t.Fatalf("FromConfig(%q) failed: %v", tt.config, err)
+			}
+			if !got.Equal(tt.want) {
+				t.Errorf("FromConfig(%q) = %+v, want %+v", tt.config, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStaticDhcpHostFromConfigErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+	}{
+		{name: "missing prefix", config: "dhcp-range=192.168.1.10,192.168.1.20"},
+		{name: "IPv6 address out of brackets", config: "dhcp-host=00:20:e0:3b:13:af,fd00::10,nas"},
+		{name: "malformed hardware address", config: "dhcp-host=00:20:zz:3b:13:af,nas"},
+		{name: "malformed IPv6 address", config: "dhcp-host=[fd00::zz],nas"},
+		{name: "two IPv4 addresses", config: "dhcp-host=192.168.1.10,192.168.1.11"},
+		{name: "two client IDs", config: "dhcp-host=id:01,id:02"},
+		{name: "two host names", config: "dhcp-host=nas,storage"},
+		{name: "three names", config: "dhcp-host=nas,1d,2h"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got StaticDhcpHost
+			if err := got.FromConfig(tt.config); !errors.Is(err, ErrInvalidDHCPHost) {
+				t.Errorf("FromConfig(%q) error = %v, want %v", tt.config, err, ErrInvalidDHCPHost)
+			}
+		})
+	}
+}
+
+func TestStaticDhcpHostRoundTrip(t *testing.T) {
+	tests := []string{
+		"dhcp-host=00:20:e0:3b:13:af,192.168.1.10,nas",
+		"dhcp-host=00:20:e0:3b:13:af,06-00:20:e0:3b:13:*,id:01:02:03,set:known,tag:lan,192.168.1.10,[fd00::10],[::56]/120,nas,12h,ignore",
+		"dhcp-host=id:*,192.168.1.10",
+		"dhcp-host=00:20:e0:3b:13:af,1d,2h",
+		"dhcp-host=00:20:e0:3b:13:af,42,infinite",
+		"dhcp-host=00:20:e0:3b:13:af,infinite",
+		"dhcp-host=00:20:e0:3b:13:af,ignore",
+		"dhcp-host=set:fd00::1,tag:v6:lan,nas",
+	}
+
+	for _, config := range tests {
+		t.Run(config, func(t *testing.T) {
+			var host StaticDhcpHost
+			if err := host.FromConfig(config); err != nil {
+				t.Fatalf("FromConfig(%q) failed: %v", config, err)
+			}
+			if got := host.ToConfig(); got != config {
+				t.Errorf("ToConfig() = %q, want %q", got, config)
+			}
+
+			var parsed StaticDhcpHost
+			if err := parsed.FromConfig(host.ToConfig()); err != nil {
+				t.Fatalf("FromConfig(%q) failed: %v", host.ToConfig(), err)
+			}
+			if !parsed.Equal(host) {
+				t.Errorf("FromConfig(ToConfig()) = %+v, want %+v", parsed, host)
+			}
+		})
+	}
+}
+
+func TestIsHostName(t *testing.T) {
+	tests := []struct {
+		name string
+		want bool
+	}{
+		{name: "nas", want: true},
+		{name: "nas-12h", want: true},
+		{name: "ignored", want: true},
+		{name: "ignore", want: false},
+		{name: "infinite", want: false},
+		{name: "12h", want: false},
+		{name: "42", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsHostName(tt.name); got != tt.want {
+				t.Errorf("IsHostName(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+
+			// The accepted names are read back as the host name, the rejected ones are not
+			host := StaticDhcpHost{IPAddress: net.ParseIP("192.168.1.10"), HostName: tt.name}
+			var parsed StaticDhcpHost
+			if err := parsed.FromConfig(host.ToConfig()); err != nil {
+				t.Fatalf("FromConfig(%q) failed: %v", host.ToConfig(), err)
+			}
+			if roundTrip := parsed.HostName == tt.name; roundTrip != tt.want {
+				t.Errorf("FromConfig(%q).HostName = %q", host.ToConfig(), parsed.HostName)
+			}
+		})
+	}
+}
@@ -0,0 +1,246 @@
+package query
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"path"
+	"regexp"
+	"strings"
+
+	"golang.org/x/exp/slices"
+)
+
+// Sort fields
+const (
+	SortByIP       = "ip"
+	SortByMac      = "mac"
+	SortByHostName = "hostname"
+)
+
+var (
+	ErrInvalidSort   = errors.New("invalid sort field")
+	ErrInvalidVendor = errors.New("invalid MAC vendor prefix")
+	ErrInvalidCursor = errors.New("invalid cursor")
+)
+
+// Query selects, sorts and paginates a list of DHCP records. The zero value selects all the
+// records, in their original order.
+type Query struct {
+	// Subnet selects the records with any IP address within it
+	Subnet *net.IPNet
+	// HostName selects the records whose host name matches the glob pattern, case insensitively
+	HostName string
+	// HostNameRegexp selects the records whose host name matches the regular expression
+	HostNameRegexp *regexp.Regexp
+	// Vendor selects the records with any MAC address starting with the vendor prefix
+	Vendor string
+	// SortBy is one of the SortBy* constants, or empty to keep the original order
+	SortBy     string
+	Descending bool
+	Offset     int
+	// Limit is the maximum number of records of a page, or 0 for no limit
+	Limit int
+	// Cursor resumes the listing right after the last record of a previous page
+	Cursor string
+}
+
+// Record holds the fields of a DHCP record that can be queried.
+type Record struct {
+	MacAddresses []string
+	IPAddresses  []net.IP
+	HostName     string
+	// Key must uniquely identify the record, it is used to resume paginated listings
+	Key string
+}
+
+type Page[T any] struct {
+	Items []T
+	// Total is the number of records matching the query, regardless of the pagination
+	Total int
+	// NextCursor resumes the listing after this page, it is empty on the last page
+	NextCursor string
+}
+
+func ParseSort(sort string) (string, bool, error) {
+	field, descending := strings.CutPrefix(sort, "-")
+	switch field {
+	case SortByIP, SortByMac, SortByHostName:
+		return field, descending, nil
+	default:
+		return "", false, ErrInvalidSort
+	}
+}
+
+// ParseVendor normalizes a MAC vendor prefix (e.g. 00:1A:2B, 00-1a-2b or 001a2b).
+func ParseVendor(vendor string) (string, error) {
+	normalized := strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "").Replace(vendor))
+	if len(normalized) == 0 || len(normalized) > 12 || len(normalized)%2 != 0 {
+		return "", ErrInvalidVendor
+	}
+	if _, err := hex.DecodeString(normalized); err != nil {
+		return "", ErrInvalidVendor
+	}
+
+	return normalized, nil
+}
+
+func ParseHostNameGlob(pattern string) (string, error) {
+	pattern = strings.ToLower(pattern)
+	if _, err := path.Match(pattern, ""); err != nil {
+		return "", err
+	}
+
+	return pattern, nil
+}
+
+// Apply returns the page of items selected by the query. The record function extracts the
+// queryable fields of an item.
+func Apply[T any](items []T, record func(T) Record, q Query) (*Page[T], error) {
+	type entry struct {
+		item T
+		key  cursor
+	}
+
+	entries := make([]entry, 0, len(items))
+	for i, item := range items {
+		r := record(item)
+		if !q.match(r) {
+			continue
+		}
+		entries = append(entries, entry{item: item, key: q.cursor(i, r)})
+	}
+
+	if q.SortBy != "" {
+		slices.SortStableFunc(entries, func(a, b entry) bool {
+			return q.less(a.key, b.key)
+		})
+	}
+
+	page := &Page[T]{Total: len(entries)}
+
+	start := q.Offset
+	if q.Cursor != "" {
+		after, err := decodeCursor(q.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		start = slices.IndexFunc(entries, func(e entry) bool {
+			return q.less(after, e.key)
+		})
+		if start < 0 {
+			start = len(entries)
+		}
+	}
+	if start > len(entries) {
+		start = len(entries)
+	}
+
+	end := len(entries)
+	if q.Limit > 0 && start+q.Limit < end {
+		end = start + q.Limit
+		page.NextCursor = entries[end-1].key.encode()
+	}
+
+	page.Items = make([]T, 0, end-start)
+	for _, e := range entries[start:end] {
+		page.Items = append(page.Items, e.item)
+	}
+
+	return page, nil
+}
+
+func (q Query) match(r Record) bool {
+	if q.Subnet != nil && !slices.ContainsFunc(r.IPAddresses, q.Subnet.Contains) {
+		return false
+	}
+
+	hostName := strings.ToLower(r.HostName)
+	if q.HostName != "" {
+		if matched, _ := path.Match(q.HostName, hostName); !matched {
+			return false
+		}
+	}
+
+	if q.HostNameRegexp != nil && !q.HostNameRegexp.MatchString(r.HostName) {
+		return false
+	}
+
+	if q.Vendor != "" && !slices.ContainsFunc(r.MacAddresses, func(mac string) bool {
+		return strings.HasPrefix(macHex(mac), q.Vendor)
+	}) {
+		return false
+	}
+
+	return true
+}
+
+// cursor is the position of a record within the sorted listing.
+type cursor struct {
+	Value string `json:"v"`
+	Key   string `json:"k"`
+}
+
+func (q Query) cursor(index int, r Record) cursor {
+	var value string
+	switch q.SortBy {
+	case SortByIP:
+		if len(r.IPAddresses) > 0 {
+			value = hex.EncodeToString(r.IPAddresses[0].To16())
+		}
+	case SortByMac:
+		if len(r.MacAddresses) > 0 {
+			value = macHex(r.MacAddresses[0])
+		}
+	case SortByHostName:
+		value = strings.ToLower(r.HostName)
+	default:
+		// Keep the original order
+		value = fmt.Sprintf("%012d", index)
+	}
+
+	return cursor{Value: value, Key: r.Key}
+}
+
+func (q Query) less(a, b cursor) bool {
+	if q.Descending {
+		a, b = b, a
+	}
+
+	if a.Value != b.Value {
+		return a.Value < b.Value
+	}
+	return a.Key < b.Key
+}
+
+func (c cursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(encoded string) (cursor, error) {
+	c := cursor{}
+
+	data, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	if err := json.Unmarshal(data, &c); err != nil {
+		return c, ErrInvalidCursor
+	}
+
+	return c, nil
+}
+
+// macHex strips the hardware type and the separators out of a MAC address.
+func macHex(mac string) string {
+	if hwType, address, found := strings.Cut(mac, "-"); found && len(hwType) <= 2 && strings.Contains(address, ":") {
+		mac = address
+	}
+
+	return strings.ToLower(strings.NewReplacer(":", "", "-", "", ".", "").Replace(mac))
+}
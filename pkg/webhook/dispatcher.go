@@ -0,0 +1,186 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/event"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+// Headers of the deliveries
+const (
+	SignatureHeader = "X-Dmm-Signature"
+	EventHeader     = "X-Dmm-Event"
+	DeliveryHeader  = "X-Dmm-Delivery"
+)
+
+const (
+	signaturePrefix = "sha256="
+	deliveryTimeout = 10 * time.Second
+	// publishBuffer is the number of published events waiting to be queued before they are dropped
+	publishBuffer = 1000
+)
+
+// Subscription delivers the events to the URL, signed with the secret.
+type Subscription struct {
+	URL    string
+	Secret string
+	// Events selects the types of the delivered events, all of them when empty
+	Events []string
+}
+
+func (s Subscription) accepts(e *event.Event) bool {
+	return len(s.Events) == 0 || slices.Contains(s.Events, e.Type)
+}
+
+// Retry sets how many times a delivery is attempted, waiting for the delay between the attempts,
+// doubled after each one up to the max delay, or without limit when the max delay is zero.
+type Retry struct {
+	Attempts int
+	Delay    time.Duration
+	MaxDelay time.Duration
+}
+
+func (r Retry) backoff(attempts int) time.Duration {
+	delay := r.Delay
+	for i := 1; i < attempts && (r.MaxDelay == 0 || delay < r.MaxDelay) && delay <= math.MaxInt64/2; i++ {
+		delay *= 2
+	}
+	if r.MaxDelay > 0 && delay > r.MaxDelay {
+		delay = r.MaxDelay
+	}
+
+	return delay
+}
+
+var ErrDuplicatedSubscription = errors.New("duplicated webhook subscription")
+
+// Dispatcher delivers the published events to the subscriptions. The deliveries are queued on disk,
+// so the ones still pending are resumed after a restart, and each subscription receives its events
+// in the order they were published.
+type Dispatcher struct {
+	queues     []*queue
+	deliveries chan published
+}
+
+// published is an event handed over to be queued, along with its encoding.
+type published struct {
+	event *event.Event
+	body  []byte
+}
+
+// NewDispatcher creates the dispatcher, queuing the deliveries of every subscription into its own
+// directory within dir.
+func NewDispatcher(dir string, subscriptions []Subscription, retry Retry) (*Dispatcher, error) {
+	d := &Dispatcher{deliveries: make(chan published, publishBuffer)}
+	client := &http.Client{Timeout: deliveryTimeout}
+	for _, subscription := range subscriptions {
+		if slices.ContainsFunc(d.queues, func(q *queue) bool { return q.subscription.URL == subscription.URL }) {
+			return nil, fmt.Errorf("%w: %s", ErrDuplicatedSubscription, subscription.URL)
+		}
+
+		q, err := newQueue(filepath.Join(dir, queueName(subscription.URL)), subscription, retry, client)
+		if err != nil {
+			return nil, err
+		}
+		d.queues = append(d.queues, q)
+	}
+
+	for _, q := range d.queues {
+		go q.run()
+	}
+	go d.run()
+
+	return d, nil
+}
+
+// Publish hands the event over to be queued by the dispatcher, so the queue files are not written
+// by the request publishing it. Publish never blocks, the event is dropped instead when too many
+// events are already waiting to be queued.
+func (d *Dispatcher) Publish(e *event.Event) {
+	body, err := json.Marshal(e)
+	if err != nil {
+		slog.Error("Error encoding the event",
+			slog.String("event", e.ID),
+			slog.String("error", err.Error()),
+		)
+		return
+	}
+
+	select {
+	case d.deliveries <- published{event: e, body: body}:
+	default:
+		slog.Error("Dropping the event, too many events are waiting to be queued",
+			slog.String("event", e.ID),
+			slog.String("type", e.Type),
+		)
+	}
+}
+
+// run queues the deliveries of the published events, in the order they were published.
+func (d *Dispatcher) run() {
+	for p := range d.deliveries {
+		d.push(p.event, p.body)
+	}
+}
+
+func (d *Dispatcher) push(e *event.Event, body []byte) {
+	for _, q := range d.queues {
+		if !q.subscription.accepts(e) {
+			continue
+		}
+		if err := q.push(e, body); err != nil {
+			slog.Error("Error queuing the webhook delivery",
+				slog.String("url", q.subscription.URL),
+				slog.String("event", e.ID),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// Sign returns the signature of the body, as sent in the SignatureHeader.
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return signaturePrefix + hex.EncodeToString(mac.Sum(nil))
+}
+
+func queueName(url string) string {
+	sum := sha256.Sum256([]byte(url))
+	return hex.EncodeToString(sum[:8])
+}
+
+func deliver(client *http.Client, subscription Subscription, d *delivery) error {
+	request, err := http.NewRequest(http.MethodPost, subscription.URL, bytes.NewReader(d.Body))
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Content-Type", "application/json")
+	request.Header.Set(EventHeader, d.Type)
+	request.Header.Set(DeliveryHeader, d.ID)
+	request.Header.Set(SignatureHeader, Sign(subscription.Secret, d.Body))
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return fmt.Errorf("unexpected response status: %s", response.Status)
+	}
+
+	return nil
+}
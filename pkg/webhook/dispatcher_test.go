@@ -0,0 +1,52 @@
+package webhook
+
+import (
+	"testing"
+	"time"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/event"
+)
+
+func TestRetryBackoff(t *testing.T) {
+	tests := []struct {
+		name     string
+		retry    Retry
+		attempts int
+		want     time.Duration
+	}{
+		{name: "first attempt", retry: Retry{Delay: time.Second, MaxDelay: time.Minute}, attempts: 1, want: time.Second},
+		{name: "doubled", retry: Retry{Delay: time.Second, MaxDelay: time.Minute}, attempts: 4, want: 8 * time.Second},
+		{name: "capped", retry: Retry{Delay: time.Second, MaxDelay: time.Minute}, attempts: 10, want: time.Minute},
+		{name: "no cap", retry: Retry{Delay: time.Second}, attempts: 10, want: 512 * time.Second},
+		{name: "no cap overflow", retry: Retry{Delay: time.Second}, attempts: 100, want: time.Second << 33},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.retry.backoff(tt.attempts); got != tt.want {
+				t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDispatcherPublishFull(t *testing.T) {
+	// Nothing queues the published events, so the buffer is full after the first one
+	d := &Dispatcher{deliveries: make(chan published, 1)}
+
+	done := make(chan struct{})
+	go func() {
+		d.Publish(&event.Event{ID: "1"})
+		d.Publish(&event.Event{ID: "2"})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Publish() blocked on the full buffer")
+	}
+	if p := <-d.deliveries; p.event.ID != "1" {
+		t.Errorf("queued event %q, want %q", p.event.ID, "1")
+	}
+}
@@ -0,0 +1,217 @@
+package webhook
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gringolito/dnsmasq-manager/pkg/event"
+	"golang.org/x/exp/slices"
+	"golang.org/x/exp/slog"
+)
+
+const (
+	deliveryExtension = ".json"
+	stagingPrefix     = "."
+	// errorDelay is waited for after an error of the queue itself, before trying again
+	errorDelay = time.Minute
+)
+
+// delivery is a queued event, stored as a file of the queue directory.
+type delivery struct {
+	ID       string
+	Type     string
+	Attempts int
+	// Body is the encoded event, as it is signed and delivered
+	Body json.RawMessage
+}
+
+// queue delivers the events of a subscription one at a time, in the order they were pushed. The
+// files of the deliveries are named after the time they were pushed, so listing the directory
+// yields them in order.
+type queue struct {
+	dir          string
+	subscription Subscription
+	retry        Retry
+	client       *http.Client
+	wake         chan struct{}
+}
+
+func newQueue(dir string, subscription Subscription, retry Retry, client *http.Client) (*queue, error) {
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, err
+	}
+
+	return &queue{
+		dir:          dir,
+		subscription: subscription,
+		retry:        retry,
+		client:       client,
+		wake:         make(chan struct{}, 1),
+	}, nil
+}
+
+// push stores the delivery of the event, written into a staging file first so an interrupted
+// write never leaves a partial delivery in the queue.
+func (q *queue) push(e *event.Event, body []byte) error {
+	data, err := json.Marshal(&delivery{ID: e.ID, Type: e.Type, Body: body})
+	if err != nil {
+		return err
+	}
+
+	name := fmt.Sprintf("%020d-%s%s", time.Now().UnixNano(), e.ID, deliveryExtension)
+	if err := q.write(name, data); err != nil {
+		return err
+	}
+
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+
+	return nil
+}
+
+func (q *queue) write(name string, data []byte) error {
+	staging := filepath.Join(q.dir, stagingPrefix+name)
+	file, err := os.OpenFile(staging, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+
+	_, err = file.Write(data)
+	if err == nil {
+		err = file.Sync()
+	}
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(staging)
+		return err
+	}
+
+	return os.Rename(staging, filepath.Join(q.dir, name))
+}
+
+// next returns the name of the oldest delivery, or an empty string when the queue is empty.
+func (q *queue) next() (string, error) {
+	entries, err := os.ReadDir(q.dir)
+	if err != nil {
+		return "", err
+	}
+
+	names := []string{}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && !strings.HasPrefix(name, stagingPrefix) && strings.HasSuffix(name, deliveryExtension) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return "", nil
+	}
+
+	slices.Sort(names)
+	return names[0], nil
+}
+
+func (q *queue) run() {
+	for {
+		name, err := q.next()
+		if err != nil {
+			slog.Error("Error reading the webhook queue",
+				slog.String("dir", q.dir),
+				slog.String("error", err.Error()),
+			)
+			time.Sleep(errorDelay)
+			continue
+		}
+		if name == "" {
+			<-q.wake
+			continue
+		}
+
+		q.process(name)
+	}
+}
+
+// process attempts the delivery, removing it from the queue once it succeeds or runs out of
+// attempts, and waiting for the backoff delay after a failed attempt otherwise.
+func (q *queue) process(name string) {
+	path := filepath.Join(q.dir, name)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		slog.Error("Error reading the webhook delivery",
+			slog.String("file", path),
+			slog.String("error", err.Error()),
+		)
+		time.Sleep(errorDelay)
+		return
+	}
+
+	d := &delivery{}
+	if err := json.Unmarshal(data, d); err != nil {
+		slog.Error("Dropping invalid webhook delivery",
+			slog.String("file", path),
+			slog.String("error", err.Error()),
+		)
+		q.remove(path)
+		return
+	}
+
+	err = deliver(q.client, q.subscription, d)
+	if err == nil {
+		slog.Debug("Webhook delivered",
+			slog.String("url", q.subscription.URL),
+			slog.String("event", d.ID),
+		)
+		q.remove(path)
+		return
+	}
+
+	d.Attempts++
+	if d.Attempts >= q.retry.Attempts {
+		slog.Error("Dropping webhook delivery, no attempts left",
+			slog.String("url", q.subscription.URL),
+			slog.String("event", d.ID),
+			slog.Int("attempts", d.Attempts),
+			slog.String("error", err.Error()),
+		)
+		q.remove(path)
+		return
+	}
+
+	delay := q.retry.backoff(d.Attempts)
+	slog.Warn("Webhook delivery failed, retrying",
+		slog.String("url", q.subscription.URL),
+		slog.String("event", d.ID),
+		slog.Int("attempts", d.Attempts),
+		slog.Duration("delay", delay),
+		slog.String("error", err.Error()),
+	)
+	if data, err := json.Marshal(d); err == nil {
+		if err := q.write(name, data); err != nil {
+			slog.Error("Error updating the webhook delivery",
+				slog.String("file", path),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+	time.Sleep(delay)
+}
+
+func (q *queue) remove(path string) {
+	if err := os.Remove(path); err != nil {
+		slog.Error("Error removing the webhook delivery",
+			slog.String("file", path),
+			slog.String("error", err.Error()),
+		)
+		// Keep the queue from delivering it over and over
+		time.Sleep(errorDelay)
+	}
+}